@@ -0,0 +1,178 @@
+// admin.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RouteEvent describes a change made to the routing table through the admin
+// API, for consumption by SSE subscribers.
+type RouteEvent struct {
+	Type      string    `json:"type"` // "register" or "drain"
+	Service   string    `json:"service"`
+	Endpoints []string  `json:"endpoints,omitempty"`
+	Protocol  string    `json:"protocol,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// RouteEventBroadcaster fans out RouteEvents to any number of SSE subscribers.
+type RouteEventBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan RouteEvent]struct{}
+}
+
+// NewRouteEventBroadcaster creates an empty RouteEventBroadcaster.
+func NewRouteEventBroadcaster() *RouteEventBroadcaster {
+	return &RouteEventBroadcaster{subscribers: make(map[chan RouteEvent]struct{})}
+}
+
+// Subscribe registers a new subscriber channel; call the returned function to unsubscribe.
+func (b *RouteEventBroadcaster) Subscribe() (<-chan RouteEvent, func()) {
+	ch := make(chan RouteEvent, 16)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		close(ch)
+		b.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// Publish delivers event to every current subscriber, dropping it for any
+// subscriber whose buffer is full rather than blocking the publisher.
+func (b *RouteEventBroadcaster) Publish(event RouteEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// routeView is the JSON representation of one service's routing entry,
+// returned by the admin routes API.
+type routeView struct {
+	Endpoints []string `json:"endpoints"`
+	Protocol  string   `json:"protocol"`
+}
+
+// RegisterRoute adds or overrides the routing table entry for serviceName
+// and records it as an admin override so it survives every future
+// UpdateRoutes refresh, until superseded by another RegisterRoute or
+// DrainRoute call. Subscribers are notified of the change.
+func (sm *ServiceMeshHandler) RegisterRoute(serviceName string, endpoints []string, protocol string) {
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	sm.mu.Lock()
+	sm.routes[serviceName] = endpoints
+	sm.protocols[serviceName] = protocol
+	sm.overrides[serviceName] = routeOverride{endpoints: endpoints, protocol: protocol}
+	sm.mu.Unlock()
+
+	sm.Events.Publish(RouteEvent{Type: "register", Service: serviceName, Endpoints: endpoints, Protocol: protocol, Time: time.Now()})
+}
+
+// DrainRoute empties serviceName's endpoint list so new requests receive a
+// 404 while the entry itself, and its history, is preserved. The emptied
+// list is recorded as an admin override so Discovery can't repopulate it on
+// the next UpdateRoutes refresh.
+func (sm *ServiceMeshHandler) DrainRoute(serviceName string) {
+	sm.mu.Lock()
+	sm.routes[serviceName] = nil
+	sm.overrides[serviceName] = routeOverride{endpoints: nil, protocol: sm.protocols[serviceName]}
+	sm.mu.Unlock()
+
+	sm.Events.Publish(RouteEvent{Type: "drain", Service: serviceName, Time: time.Now()})
+}
+
+// routesHandler implements the /mesh/routes admin API: GET lists the
+// current routing table, PUT registers or overrides a route, and DELETE
+// drains one.
+func (sm *ServiceMeshHandler) routesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sm.mu.RLock()
+		views := make(map[string]routeView, len(sm.routes))
+		for name, endpoints := range sm.routes {
+			views[name] = routeView{Endpoints: endpoints, Protocol: sm.protocols[name]}
+		}
+		sm.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(views)
+
+	case http.MethodPut:
+		var req struct {
+			Name     string   `json:"name"`
+			URLs     []string `json:"urls"`
+			Protocol string   `json:"protocol"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		sm.RegisterRoute(req.Name, req.URLs, req.Protocol)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name query parameter is required", http.StatusBadRequest)
+			return
+		}
+		sm.DrainRoute(name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// routeEventsHandler streams route changes to the client as Server-Sent Events.
+func (sm *ServiceMeshHandler) routeEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events, unsubscribe := sm.Events.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// balancer.go
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoEndpoints is returned by a Balancer when a service has no healthy backends to pick from.
+var ErrNoEndpoints = errors.New("no endpoints available for service")
+
+// Balancer selects a backend endpoint for a service from a set of candidates.
+type Balancer interface {
+	// Name identifies the balancing strategy, used in logs and diagnostics.
+	Name() string
+	// Pick selects one of endpoints for service, optionally consulting stats
+	// for load-aware strategies.
+	Pick(service string, endpoints []string, stats *StatsRegistry) (string, error)
+}
+
+// EndpointStats tracks rolling latency/error/in-flight counters for a single backend.
+type EndpointStats struct {
+	mu         sync.Mutex
+	inFlight   int64
+	avgLatency time.Duration
+	requests   int64
+	errors     int64
+}
+
+// ewmaAlpha weights how quickly avgLatency reacts to new samples.
+const ewmaAlpha = 0.2
+
+// Start marks the beginning of a request against this endpoint.
+func (s *EndpointStats) Start() {
+	atomic.AddInt64(&s.inFlight, 1)
+}
+
+// Done records the outcome of a request against this endpoint.
+func (s *EndpointStats) Done(latency time.Duration, err error) {
+	atomic.AddInt64(&s.inFlight, -1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if err != nil {
+		s.errors++
+	}
+	if s.avgLatency == 0 {
+		s.avgLatency = latency
+		return
+	}
+	s.avgLatency = time.Duration(float64(s.avgLatency)*(1-ewmaAlpha) + float64(latency)*ewmaAlpha)
+}
+
+// Snapshot returns the current in-flight count and average latency.
+func (s *EndpointStats) Snapshot() (inFlight int64, avgLatency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return atomic.LoadInt64(&s.inFlight), s.avgLatency
+}
+
+// StatsRegistry holds per-endpoint stats keyed by endpoint URL.
+type StatsRegistry struct {
+	mu    sync.RWMutex
+	stats map[string]*EndpointStats
+}
+
+// NewStatsRegistry creates an empty StatsRegistry.
+func NewStatsRegistry() *StatsRegistry {
+	return &StatsRegistry{stats: make(map[string]*EndpointStats)}
+}
+
+// Get returns the stats for endpoint, creating them on first use.
+func (r *StatsRegistry) Get(endpoint string) *EndpointStats {
+	r.mu.RLock()
+	s, ok := r.stats[endpoint]
+	r.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.stats[endpoint]; ok {
+		return s
+	}
+	s = &EndpointStats{}
+	r.stats[endpoint] = s
+	return s
+}
+
+// roundRobinBalancer cycles through endpoints in order, per service.
+type roundRobinBalancer struct {
+	counters sync.Map // service -> *uint64
+}
+
+// NewRoundRobinBalancer creates a Balancer that cycles through endpoints in order.
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Name() string { return "round-robin" }
+
+func (b *roundRobinBalancer) Pick(service string, endpoints []string, _ *StatsRegistry) (string, error) {
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoints
+	}
+	counterI, _ := b.counters.LoadOrStore(service, new(uint64))
+	counter := counterI.(*uint64)
+	n := atomic.AddUint64(counter, 1)
+	return endpoints[(n-1)%uint64(len(endpoints))], nil
+}
+
+// randomBalancer picks a uniformly random endpoint.
+type randomBalancer struct{}
+
+// NewRandomBalancer creates a Balancer that picks a uniformly random endpoint.
+func NewRandomBalancer() Balancer {
+	return &randomBalancer{}
+}
+
+func (b *randomBalancer) Name() string { return "random" }
+
+func (b *randomBalancer) Pick(_ string, endpoints []string, _ *StatsRegistry) (string, error) {
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoints
+	}
+	return endpoints[rand.Intn(len(endpoints))], nil
+}
+
+// leastConnectionsBalancer picks the endpoint with the fewest in-flight requests.
+type leastConnectionsBalancer struct{}
+
+// NewLeastConnectionsBalancer creates a Balancer that picks the endpoint with the fewest in-flight requests.
+func NewLeastConnectionsBalancer() Balancer {
+	return &leastConnectionsBalancer{}
+}
+
+func (b *leastConnectionsBalancer) Name() string { return "least-connections" }
+
+func (b *leastConnectionsBalancer) Pick(_ string, endpoints []string, stats *StatsRegistry) (string, error) {
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoints
+	}
+	best := endpoints[0]
+	bestInFlight, _ := stats.Get(best).Snapshot()
+	for _, ep := range endpoints[1:] {
+		inFlight, _ := stats.Get(ep).Snapshot()
+		if inFlight < bestInFlight {
+			best, bestInFlight = ep, inFlight
+		}
+	}
+	return best, nil
+}
+
+// ewmaP2CBalancer implements power-of-two-choices: it samples two random
+// endpoints and picks the one with the lower score of (in-flight + 1) x
+// average latency, favoring fast, lightly-loaded backends without scanning
+// the set.
+type ewmaP2CBalancer struct{}
+
+// NewEWMABalancer creates a power-of-two-choices Balancer scored on (in-flight count + 1) x average latency.
+func NewEWMABalancer() Balancer {
+	return &ewmaP2CBalancer{}
+}
+
+func (b *ewmaP2CBalancer) Name() string { return "ewma-p2c" }
+
+func (b *ewmaP2CBalancer) Pick(_ string, endpoints []string, stats *StatsRegistry) (string, error) {
+	switch len(endpoints) {
+	case 0:
+		return "", ErrNoEndpoints
+	case 1:
+		return endpoints[0], nil
+	}
+
+	i, j := rand.Intn(len(endpoints)), rand.Intn(len(endpoints)-1)
+	if j >= i {
+		j++
+	}
+	a, c := endpoints[i], endpoints[j]
+
+	aInFlight, aLatency := stats.Get(a).Snapshot()
+	cInFlight, cLatency := stats.Get(c).Snapshot()
+	if ewmaScore(aInFlight, aLatency) <= ewmaScore(cInFlight, cLatency) {
+		return a, nil
+	}
+	return c, nil
+}
+
+// ewmaScore combines in-flight count and average latency into a single
+// comparable load score; an idle endpoint with no latency samples yet
+// scores zero so it is preferred until proven otherwise.
+func ewmaScore(inFlight int64, avgLatency time.Duration) float64 {
+	if avgLatency <= 0 {
+		return float64(inFlight)
+	}
+	return float64(inFlight+1) * float64(avgLatency)
+}
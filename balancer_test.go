@@ -0,0 +1,78 @@
+// balancer_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundRobinBalancerCyclesInOrder(t *testing.T) {
+	b := NewRoundRobinBalancer()
+	endpoints := []string{"a", "b", "c"}
+	stats := NewStatsRegistry()
+
+	for i, want := range []string{"a", "b", "c", "a", "b"} {
+		got, err := b.Pick("svc", endpoints, stats)
+		if err != nil {
+			t.Fatalf("pick %d: unexpected error: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("pick %d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestRoundRobinBalancerNoEndpoints(t *testing.T) {
+	b := NewRoundRobinBalancer()
+	if _, err := b.Pick("svc", nil, NewStatsRegistry()); err != ErrNoEndpoints {
+		t.Fatalf("got error %v, want ErrNoEndpoints", err)
+	}
+}
+
+func TestLeastConnectionsBalancerPrefersFewerInFlight(t *testing.T) {
+	stats := NewStatsRegistry()
+	stats.Get("busy").Start()
+	stats.Get("busy").Start()
+	stats.Get("idle").Start()
+
+	b := NewLeastConnectionsBalancer()
+	got, err := b.Pick("svc", []string{"busy", "idle"}, stats)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "idle" {
+		t.Fatalf("got %q, want %q", got, "idle")
+	}
+}
+
+func TestEndpointStatsDoneTracksInFlightAndAverages(t *testing.T) {
+	s := &EndpointStats{}
+	s.Start()
+	inFlight, _ := s.Snapshot()
+	if inFlight != 1 {
+		t.Fatalf("in-flight after Start = %d, want 1", inFlight)
+	}
+
+	s.Done(100*time.Millisecond, nil)
+	inFlight, avg := s.Snapshot()
+	if inFlight != 0 {
+		t.Fatalf("in-flight after Done = %d, want 0", inFlight)
+	}
+	if avg != 100*time.Millisecond {
+		t.Fatalf("avgLatency after first sample = %v, want %v", avg, 100*time.Millisecond)
+	}
+
+	s.Done(200*time.Millisecond, nil)
+	if _, avg := s.Snapshot(); avg <= 100*time.Millisecond || avg >= 200*time.Millisecond {
+		t.Fatalf("avgLatency after second sample = %v, want between 100ms and 200ms", avg)
+	}
+}
+
+func TestEwmaScorePrefersUntriedEndpoints(t *testing.T) {
+	if score := ewmaScore(0, 0); score != 0 {
+		t.Fatalf("score for untried endpoint = %v, want 0", score)
+	}
+	if fast, slow := ewmaScore(0, 10*time.Millisecond), ewmaScore(0, 100*time.Millisecond); fast >= slow {
+		t.Fatalf("faster endpoint score %v should be lower than slower endpoint score %v", fast, slow)
+	}
+}
@@ -0,0 +1,158 @@
+// breaker.go
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState is the state of a CircuitBreaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker short-circuits requests to a backend that has failed
+// repeatedly, giving it time to recover before being probed again.
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu               sync.Mutex
+	state            breakerState
+	consecutiveFails int
+	openedAt         time.Time
+	halfOpenInFlight bool
+}
+
+// NewCircuitBreaker creates a CircuitBreaker that opens after
+// failureThreshold consecutive failures and stays open for openDuration
+// before allowing a single half-open trial request.
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// Allow reports whether a request may proceed to the backend right now.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.openDuration {
+			return false
+		}
+		cb.state = breakerHalfOpen
+		cb.halfOpenInFlight = true
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// RecordSuccess reports that a request succeeded, closing the breaker.
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.consecutiveFails = 0
+	cb.state = breakerClosed
+	cb.halfOpenInFlight = false
+}
+
+// RecordFailure reports that a request failed, opening the breaker once
+// failureThreshold consecutive failures have been seen.
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.halfOpenInFlight = false
+
+	if cb.state == breakerHalfOpen {
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+		return
+	}
+
+	cb.consecutiveFails++
+	if cb.consecutiveFails >= cb.failureThreshold {
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// State returns the breaker's current state for diagnostics.
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state.String()
+}
+
+// CircuitBreakerRegistry holds one CircuitBreaker per endpoint.
+type CircuitBreakerRegistry struct {
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakerRegistry creates a registry whose breakers all share the
+// given failureThreshold and openDuration.
+func NewCircuitBreakerRegistry(failureThreshold int, openDuration time.Duration) *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		breakers:         make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the CircuitBreaker for endpoint, creating it on first use.
+func (r *CircuitBreakerRegistry) Get(endpoint string) *CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[endpoint]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cb, ok := r.breakers[endpoint]; ok {
+		return cb
+	}
+	cb = NewCircuitBreaker(r.failureThreshold, r.openDuration)
+	r.breakers[endpoint] = cb
+	return cb
+}
+
+// Snapshot returns the current state of every breaker in the registry, keyed
+// by endpoint, for diagnostics.
+func (r *CircuitBreakerRegistry) Snapshot() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]string, len(r.breakers))
+	for endpoint, cb := range r.breakers {
+		out[endpoint] = cb.State()
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+// breaker_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !cb.Allow() {
+			t.Fatalf("attempt %d: Allow() = false before threshold reached", i)
+		}
+		cb.RecordFailure()
+	}
+	if cb.State() != "closed" {
+		t.Fatalf("state = %q, want %q before threshold reached", cb.State(), "closed")
+	}
+
+	cb.RecordFailure()
+	if cb.State() != "open" {
+		t.Fatalf("state = %q, want %q after threshold reached", cb.State(), "open")
+	}
+	if cb.Allow() {
+		t.Fatal("Allow() = true while breaker is open")
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+
+	cb.RecordFailure()
+	if cb.State() != "open" {
+		t.Fatalf("state = %q, want %q", cb.State(), "open")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !cb.Allow() {
+		t.Fatal("Allow() = false after openDuration elapsed")
+	}
+	if cb.State() != "half-open" {
+		t.Fatalf("state = %q, want %q", cb.State(), "half-open")
+	}
+	if cb.Allow() {
+		t.Fatal("Allow() = true for a second request while a half-open trial is in flight")
+	}
+
+	cb.RecordSuccess()
+	if cb.State() != "closed" {
+		t.Fatalf("state = %q, want %q after a successful half-open trial", cb.State(), "closed")
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+	cb.Allow()
+
+	cb.RecordFailure()
+	if cb.State() != "open" {
+		t.Fatalf("state = %q, want %q after a failed half-open trial", cb.State(), "open")
+	}
+}
+
+func TestCircuitBreakerRegistryIsolatesEndpoints(t *testing.T) {
+	reg := NewCircuitBreakerRegistry(1, time.Minute)
+	reg.Get("a").RecordFailure()
+
+	if reg.Get("a").State() != "open" {
+		t.Fatalf("endpoint a state = %q, want %q", reg.Get("a").State(), "open")
+	}
+	if reg.Get("b").State() != "closed" {
+		t.Fatalf("endpoint b state = %q, want %q", reg.Get("b").State(), "closed")
+	}
+
+	snapshot := reg.Snapshot()
+	if snapshot["a"] != "open" || snapshot["b"] != "closed" {
+		t.Fatalf("snapshot = %v, want a=open b=closed", snapshot)
+	}
+}
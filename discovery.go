@@ -0,0 +1,232 @@
+// discovery.go
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovery abstracts how the mesh learns about registered services, so the
+// HTTP registry can be swapped for a static file, Consul, or etcd without
+// touching ServiceMeshHandler.
+type Discovery interface {
+	// GetServices returns the full current set of registered services.
+	GetServices() ([]Service, error)
+}
+
+// Compile-time assertion that RegistryClient satisfies Discovery.
+var _ Discovery = (*RegistryClient)(nil)
+
+// FileDiscovery reads services from a local JSON file containing an array
+// of Service objects, re-reading it on every GetServices call. Pair it with
+// Watch to pick up edits without waiting for the periodic route refresh.
+type FileDiscovery struct {
+	Path string
+
+	mu          sync.Mutex
+	lastModTime time.Time
+}
+
+// NewFileDiscovery creates a FileDiscovery that reads services from path.
+func NewFileDiscovery(path string) *FileDiscovery {
+	return &FileDiscovery{Path: path}
+}
+
+// GetServices implements Discovery by reading and parsing Path.
+func (fd *FileDiscovery) GetServices() ([]Service, error) {
+	data, err := os.ReadFile(fd.Path)
+	if err != nil {
+		return nil, err
+	}
+	var services []Service
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", fd.Path, err)
+	}
+	return services, nil
+}
+
+// Watch polls Path's modification time every interval and calls onChange
+// whenever it advances, until stop is closed. Run it in its own goroutine.
+func (fd *FileDiscovery) Watch(interval time.Duration, stop <-chan struct{}, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(fd.Path)
+			if err != nil {
+				continue
+			}
+
+			fd.mu.Lock()
+			changed := info.ModTime().After(fd.lastModTime)
+			if changed {
+				fd.lastModTime = info.ModTime()
+			}
+			fd.mu.Unlock()
+
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
+
+// ConsulDiscovery discovers services from Consul's HTTP catalog API,
+// returning only instances passing health checks.
+type ConsulDiscovery struct {
+	Addr   string
+	Client *http.Client
+}
+
+// NewConsulDiscovery creates a ConsulDiscovery against the Consul HTTP API at addr (e.g. "http://localhost:8500").
+func NewConsulDiscovery(addr string) *ConsulDiscovery {
+	return &ConsulDiscovery{
+		Addr:   strings.TrimRight(addr, "/"),
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type consulHealthEntry struct {
+	Service struct {
+		Service string   `json:"Service"`
+		Address string   `json:"Address"`
+		Port    int      `json:"Port"`
+		Tags    []string `json:"Tags"`
+	} `json:"Service"`
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+}
+
+// GetServices implements Discovery by listing the Consul catalog and
+// resolving each service name's passing instances.
+func (cd *ConsulDiscovery) GetServices() ([]Service, error) {
+	var names map[string][]string
+	if err := cd.getJSON("/v1/catalog/services", &names); err != nil {
+		return nil, err
+	}
+
+	services := make([]Service, 0, len(names))
+	for name := range names {
+		var entries []consulHealthEntry
+		path := fmt.Sprintf("/v1/health/service/%s?passing=true", name)
+		if err := cd.getJSON(path, &entries); err != nil {
+			return nil, err
+		}
+
+		var urls []string
+		for _, e := range entries {
+			addr := e.Service.Address
+			if addr == "" {
+				addr = e.Node.Address
+			}
+			urls = append(urls, fmt.Sprintf("http://%s:%d", addr, e.Service.Port))
+		}
+		if len(urls) > 0 {
+			services = append(services, Service{Name: name, URLs: urls})
+		}
+	}
+	return services, nil
+}
+
+func (cd *ConsulDiscovery) getJSON(path string, out interface{}) error {
+	resp, err := cd.Client.Get(cd.Addr + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("consul %s returned %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// EtcdDiscovery discovers services stored as JSON-encoded Service values
+// under a key prefix in etcd, via etcd's v3 JSON gRPC-gateway API.
+type EtcdDiscovery struct {
+	Addr   string
+	Prefix string
+	Client *http.Client
+}
+
+// NewEtcdDiscovery creates an EtcdDiscovery against the etcd gRPC-gateway at
+// addr (e.g. "http://localhost:2379"), reading service definitions from keys
+// under prefix.
+func NewEtcdDiscovery(addr, prefix string) *EtcdDiscovery {
+	return &EtcdDiscovery{
+		Addr:   strings.TrimRight(addr, "/"),
+		Prefix: prefix,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type etcdRangeResponse struct {
+	Kvs []struct {
+		Value string `json:"value"`
+	} `json:"kvs"`
+}
+
+// GetServices implements Discovery by issuing a range query over Prefix and
+// decoding each value as a Service.
+func (ed *EtcdDiscovery) GetServices() ([]Service, error) {
+	reqBody, err := json.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(ed.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(ed.Prefix)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ed.Client.Post(ed.Addr+"/v3/kv/range", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("etcd range query returned %d", resp.StatusCode)
+	}
+
+	var rangeResp etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rangeResp); err != nil {
+		return nil, err
+	}
+
+	services := make([]Service, 0, len(rangeResp.Kvs))
+	for _, kv := range rangeResp.Kvs {
+		raw, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		var service Service
+		if err := json.Unmarshal(raw, &service); err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// prefixRangeEnd computes etcd's conventional range_end for a prefix query:
+// prefix with its last byte incremented, so the range covers every key that
+// starts with prefix.
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
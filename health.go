@@ -0,0 +1,165 @@
+// health.go
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthCheckConfig configures the active health checker.
+type HealthCheckConfig struct {
+	// Path is appended to each endpoint URL when probing, e.g. "/healthz".
+	Path string
+	// Interval is the time between probe rounds.
+	Interval time.Duration
+	// Timeout bounds a single probe request.
+	Timeout time.Duration
+	// HealthyThreshold is the number of consecutive successful probes
+	// required before an unhealthy endpoint is brought back into rotation.
+	HealthyThreshold int
+	// UnhealthyThreshold is the number of consecutive failed probes
+	// required before a healthy endpoint is ejected from rotation.
+	UnhealthyThreshold int
+}
+
+// DefaultHealthCheckConfig returns sane defaults for active health checking.
+func DefaultHealthCheckConfig() HealthCheckConfig {
+	return HealthCheckConfig{
+		Path:               "/healthz",
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 3,
+	}
+}
+
+// endpointHealth tracks the consecutive-probe-result state machine for one endpoint.
+type endpointHealth struct {
+	mu              sync.Mutex
+	healthy         bool
+	consecutiveOK   int
+	consecutiveFail int
+}
+
+// HealthChecker periodically probes backend endpoints and tracks which are
+// currently healthy, so the mesh can route around failing backends.
+type HealthChecker struct {
+	config HealthCheckConfig
+	client *http.Client
+
+	mu     sync.RWMutex
+	health map[string]*endpointHealth
+
+	stop chan struct{}
+}
+
+// NewHealthChecker creates a HealthChecker using config.
+func NewHealthChecker(config HealthCheckConfig) *HealthChecker {
+	return &HealthChecker{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		health: make(map[string]*endpointHealth),
+		stop:   make(chan struct{}),
+	}
+}
+
+// stateFor returns the endpointHealth for endpoint, creating it (optimistically
+// healthy) on first use.
+func (hc *HealthChecker) stateFor(endpoint string) *endpointHealth {
+	hc.mu.RLock()
+	s, ok := hc.health[endpoint]
+	hc.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if s, ok := hc.health[endpoint]; ok {
+		return s
+	}
+	s = &endpointHealth{healthy: true}
+	hc.health[endpoint] = s
+	return s
+}
+
+// IsHealthy reports whether endpoint is currently considered healthy. An
+// endpoint that has never been probed is assumed healthy.
+func (hc *HealthChecker) IsHealthy(endpoint string) bool {
+	s := hc.stateFor(endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy
+}
+
+// probe issues a single health check request against endpoint.
+func (hc *HealthChecker) probe(endpoint string) bool {
+	url := strings.TrimRight(endpoint, "/") + hc.config.Path
+	resp, err := hc.client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// record applies the outcome of one probe to endpoint's state machine.
+func (hc *HealthChecker) record(endpoint string, ok bool) {
+	s := hc.stateFor(endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ok {
+		s.consecutiveOK++
+		s.consecutiveFail = 0
+		if !s.healthy && s.consecutiveOK >= hc.config.HealthyThreshold {
+			s.healthy = true
+		}
+		return
+	}
+
+	s.consecutiveFail++
+	s.consecutiveOK = 0
+	if s.healthy && s.consecutiveFail >= hc.config.UnhealthyThreshold {
+		s.healthy = false
+	}
+}
+
+// Run probes every endpoint returned by endpoints() on config.Interval until
+// Stop is called. Intended to be launched with `go`.
+func (hc *HealthChecker) Run(endpoints func() []string) {
+	ticker := time.NewTicker(hc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hc.stop:
+			return
+		case <-ticker.C:
+			for _, endpoint := range endpoints() {
+				hc.record(endpoint, hc.probe(endpoint))
+			}
+		}
+	}
+}
+
+// Stop terminates the health checker's probe loop.
+func (hc *HealthChecker) Stop() {
+	close(hc.stop)
+}
+
+// Snapshot returns the current healthy/unhealthy state of every endpoint
+// the checker has probed or been asked about via IsHealthy, for diagnostics.
+func (hc *HealthChecker) Snapshot() map[string]bool {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	out := make(map[string]bool, len(hc.health))
+	for endpoint, s := range hc.health {
+		s.mu.Lock()
+		out[endpoint] = s.healthy
+		s.mu.Unlock()
+	}
+	return out
+}
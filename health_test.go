@@ -0,0 +1,65 @@
+// health_test.go
+package main
+
+import "testing"
+
+func TestHealthCheckerEjectsAfterUnhealthyThreshold(t *testing.T) {
+	hc := NewHealthChecker(HealthCheckConfig{HealthyThreshold: 2, UnhealthyThreshold: 3})
+
+	if !hc.IsHealthy("a") {
+		t.Fatal("a never-probed endpoint should start healthy")
+	}
+
+	hc.record("a", false)
+	hc.record("a", false)
+	if !hc.IsHealthy("a") {
+		t.Fatal("endpoint should stay healthy before UnhealthyThreshold consecutive failures")
+	}
+
+	hc.record("a", false)
+	if hc.IsHealthy("a") {
+		t.Fatal("endpoint should be ejected after UnhealthyThreshold consecutive failures")
+	}
+}
+
+func TestHealthCheckerRecoversAfterHealthyThreshold(t *testing.T) {
+	hc := NewHealthChecker(HealthCheckConfig{HealthyThreshold: 2, UnhealthyThreshold: 1})
+
+	hc.record("a", false)
+	if hc.IsHealthy("a") {
+		t.Fatal("endpoint should be unhealthy after a failing probe")
+	}
+
+	hc.record("a", true)
+	if hc.IsHealthy("a") {
+		t.Fatal("endpoint should stay unhealthy before HealthyThreshold consecutive successes")
+	}
+
+	hc.record("a", true)
+	if !hc.IsHealthy("a") {
+		t.Fatal("endpoint should recover after HealthyThreshold consecutive successes")
+	}
+}
+
+func TestHealthCheckerFailureResetsConsecutiveSuccesses(t *testing.T) {
+	hc := NewHealthChecker(HealthCheckConfig{HealthyThreshold: 2, UnhealthyThreshold: 1})
+
+	hc.record("a", false)
+	hc.record("a", true)
+	hc.record("a", false)
+	hc.record("a", true)
+	if hc.IsHealthy("a") {
+		t.Fatal("a single intervening failure should reset the consecutive-success streak")
+	}
+}
+
+func TestHealthCheckerSnapshot(t *testing.T) {
+	hc := NewHealthChecker(HealthCheckConfig{HealthyThreshold: 1, UnhealthyThreshold: 1})
+	hc.record("a", true)
+	hc.record("b", false)
+
+	snapshot := hc.Snapshot()
+	if !snapshot["a"] || snapshot["b"] {
+		t.Fatalf("snapshot = %v, want a=true b=false", snapshot)
+	}
+}
@@ -0,0 +1,237 @@
+// keystore.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// APIKey describes one issued API key and the limits that apply to it.
+type APIKey struct {
+	Key   string `json:"key"`
+	Owner string `json:"owner"`
+	// AllowedServices restricts the key to these service names; empty means
+	// the key may call any registered service.
+	AllowedServices []string `json:"allowed_services,omitempty"`
+	// RPS is the key's own token-bucket rate limit; zero disables the
+	// per-key limiter (the key is still subject to the global and per-route
+	// limiters).
+	RPS float64 `json:"rps,omitempty"`
+	// Burst is the per-key token bucket's capacity. Zero defaults to RPS
+	// rounded up to 1.
+	Burst int `json:"burst,omitempty"`
+	// MonthlyQuota caps the number of requests the key may make per
+	// calendar month; zero means unlimited.
+	MonthlyQuota int64 `json:"monthly_quota,omitempty"`
+}
+
+// Allows reports whether this key may call service.
+func (k APIKey) Allows(service string) bool {
+	if len(k.AllowedServices) == 0 {
+		return true
+	}
+	for _, allowed := range k.AllowedServices {
+		if allowed == service {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyStore abstracts how the mesh authenticates API keys and looks up their
+// quotas, so a static list can be swapped for a file, the Cdaprod Registry,
+// or an external auth service without touching authMiddleware.
+type KeyStore interface {
+	// Lookup returns the APIKey for key and whether it is known/active.
+	Lookup(key string) (APIKey, bool)
+}
+
+// StaticKeyStore is a fixed, in-memory KeyStore, useful for tests and small
+// deployments that don't need a separate keys file.
+type StaticKeyStore struct {
+	keys map[string]APIKey
+}
+
+// NewStaticKeyStore creates a StaticKeyStore from a fixed list of keys.
+func NewStaticKeyStore(keys ...APIKey) *StaticKeyStore {
+	m := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		m[k.Key] = k
+	}
+	return &StaticKeyStore{keys: m}
+}
+
+// Lookup implements KeyStore.
+func (s *StaticKeyStore) Lookup(key string) (APIKey, bool) {
+	k, ok := s.keys[key]
+	return k, ok
+}
+
+// Compile-time assertion that StaticKeyStore satisfies KeyStore.
+var _ KeyStore = (*StaticKeyStore)(nil)
+
+// FileKeyStore reads API keys from a local JSON file containing an array of
+// APIKey objects. It caches the parsed keys in memory; call Reload to pick
+// up edits.
+type FileKeyStore struct {
+	Path string
+
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+// NewFileKeyStore creates a FileKeyStore and loads path immediately.
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{Path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads Path and replaces the in-memory key set.
+func (s *FileKeyStore) Reload() error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("parsing %s: %w", s.Path, err)
+	}
+
+	m := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		m[k.Key] = k
+	}
+
+	s.mu.Lock()
+	s.keys = m
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup implements KeyStore.
+func (s *FileKeyStore) Lookup(key string) (APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[key]
+	return k, ok
+}
+
+// Compile-time assertion that FileKeyStore satisfies KeyStore.
+var _ KeyStore = (*FileKeyStore)(nil)
+
+// RegistryKeyStore fetches the full set of issued API keys from the
+// Cdaprod Registry's "/keys" endpoint and caches them. The cache is not
+// refreshed on its own; it changes only when Refresh is called.
+type RegistryKeyStore struct {
+	RegistryURL string
+	Client      *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]APIKey
+}
+
+// NewRegistryKeyStore creates a RegistryKeyStore against registryURL and
+// performs an initial fetch.
+func NewRegistryKeyStore(registryURL string) (*RegistryKeyStore, error) {
+	s := &RegistryKeyStore{
+		RegistryURL: registryURL,
+		Client:      &http.Client{Timeout: 5 * time.Second},
+	}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh fetches the current key set from the registry and replaces the cache.
+func (s *RegistryKeyStore) Refresh() error {
+	resp, err := s.Client.Get(s.RegistryURL + "/keys")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching keys from registry: status %d", resp.StatusCode)
+	}
+
+	var keys []APIKey
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return err
+	}
+
+	m := make(map[string]APIKey, len(keys))
+	for _, k := range keys {
+		m[k.Key] = k
+	}
+
+	s.mu.Lock()
+	s.keys = m
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup implements KeyStore.
+func (s *RegistryKeyStore) Lookup(key string) (APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[key]
+	return k, ok
+}
+
+// Compile-time assertion that RegistryKeyStore satisfies KeyStore.
+var _ KeyStore = (*RegistryKeyStore)(nil)
+
+// ExternalKeyStore validates keys against a third-party auth service that
+// exposes a single "does this key exist" lookup rather than a bulk listing,
+// e.g. "GET {Addr}/validate" with the key in the X-API-Key header. Unlike
+// FileKeyStore and RegistryKeyStore it performs no caching, since the
+// remote service is assumed to be authoritative and cheap to call.
+type ExternalKeyStore struct {
+	Addr   string
+	Client *http.Client
+}
+
+// NewExternalKeyStore creates an ExternalKeyStore against the validation
+// service at addr (e.g. "https://auth.internal").
+func NewExternalKeyStore(addr string) *ExternalKeyStore {
+	return &ExternalKeyStore{
+		Addr:   addr,
+		Client: &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Lookup implements KeyStore by calling out to the external service.
+func (s *ExternalKeyStore) Lookup(key string) (APIKey, bool) {
+	req, err := http.NewRequest(http.MethodGet, s.Addr+"/validate", nil)
+	if err != nil {
+		return APIKey{}, false
+	}
+	req.Header.Set("X-API-Key", key)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return APIKey{}, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return APIKey{}, false
+	}
+
+	var apiKey APIKey
+	if err := json.NewDecoder(resp.Body).Decode(&apiKey); err != nil {
+		return APIKey{}, false
+	}
+	return apiKey, true
+}
+
+// Compile-time assertion that ExternalKeyStore satisfies KeyStore.
+var _ KeyStore = (*ExternalKeyStore)(nil)
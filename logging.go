@@ -0,0 +1,12 @@
+// logging.go
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logger is the mesh's structured logger. All runtime logging (request
+// handling, discovery, health checks, proxying, startup/fatal errors) goes
+// through it so log output is consistently machine-parseable.
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
@@ -2,21 +2,56 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Service represents a microservice registered in the registry
+// Service represents a microservice registered in the registry. A service
+// may advertise a single URL or, for horizontally scaled deployments, a list
+// of backend URLs to load balance across.
 type Service struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name string   `json:"name"`
+	URL  string   `json:"url,omitempty"`
+	URLs []string `json:"urls,omitempty"`
+	// Protocol selects which registered Transport proxies requests to this
+	// service: "http" (default), "grpc", or "ws".
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// protocolOrDefault returns the service's declared protocol, defaulting to
+// "http" for services registered before Protocol existed.
+func (s Service) protocolOrDefault() string {
+	if s.Protocol == "" {
+		return "http"
+	}
+	return s.Protocol
+}
+
+// Endpoints returns the deduplicated set of backend URLs for the service,
+// merging the legacy singular URL field with URLs.
+func (s Service) Endpoints() []string {
+	seen := make(map[string]bool, len(s.URLs)+1)
+	var endpoints []string
+	add := func(u string) {
+		if u == "" || seen[u] {
+			return
+		}
+		seen[u] = true
+		endpoints = append(endpoints, u)
+	}
+	add(s.URL)
+	for _, u := range s.URLs {
+		add(u)
+	}
+	return endpoints
 }
 
 // RegistryClient simulates interaction with the Cdaprod Registry
@@ -55,15 +90,40 @@ func (rc *RegistryClient) GetServices() ([]Service, error) {
 	return services, nil
 }
 
-// Authentication Middleware
-func authMiddleware(next http.Handler, validAPIKey string) http.Handler {
+// apiKeyContextKey is the context key under which the authenticated APIKey
+// is stored, for handlers that need to account usage against it.
+type apiKeyContextKey struct{}
+
+// authMiddleware authenticates requests against keys, rejects keys not
+// permitted to call the target service, and enforces the key's rate limit
+// and monthly quota before letting the request through.
+func authMiddleware(next http.Handler, keys KeyStore, limiter *RateLimiter, quotas *QuotaTracker) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		apiKey := r.Header.Get("X-API-Key")
-		if apiKey != validAPIKey {
+		apiKey, ok := keys.Lookup(r.Header.Get("X-API-Key"))
+		if !ok {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		next.ServeHTTP(w, r)
+
+		service := serviceNameFromPath(r.URL.Path)
+		if !apiKey.Allows(service) {
+			http.Error(w, "API key not permitted for this service", http.StatusForbidden)
+			return
+		}
+
+		if allowed, retryAfter := limiter.Allow(apiKey, service); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+1)))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		if !quotas.Allow(apiKey) {
+			http.Error(w, "Monthly quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
@@ -71,14 +131,64 @@ func authMiddleware(next http.Handler, validAPIKey string) http.Handler {
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
+		requestID := r.Header.Get("X-Request-ID")
+		logger.Info("request started", "method", r.Method, "path", r.URL.Path, "request_id", requestID)
 
 		// Capture the response status
 		rr := &responseRecorder{w, http.StatusOK}
 		next.ServeHTTP(rr, r)
 
-		duration := time.Since(startTime)
-		log.Printf("Completed %d %s in %v", rr.statusCode, http.StatusText(rr.statusCode), duration)
+		logger.Info("request completed",
+			"status", rr.statusCode,
+			"status_text", http.StatusText(rr.statusCode),
+			"duration_ms", time.Since(startTime).Milliseconds(),
+			"request_id", requestID,
+		)
+	})
+}
+
+// tracingMiddleware ensures every request carries a W3C traceparent header
+// (generating one if the caller didn't supply it) and emits a root span for
+// the request.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, ctx := withIngressTrace(r)
+		ctx, span := StartSpan(ctx, "http.request")
+		span.SetAttribute("method", r.Method)
+		span.SetAttribute("path", r.URL.Path)
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// serviceNameFromPath extracts the leading path segment that metricsMiddleware
+// and ServeHTTP both treat as the target service name.
+func serviceNameFromPath(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) == 0 || parts[0] == "" {
+		return "unknown"
+	}
+	return parts[0]
+}
+
+// metricsMiddleware records request count, latency, and in-flight gauges for
+// every proxied request.
+func metricsMiddleware(next http.Handler, metrics *MetricsRegistry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service := serviceNameFromPath(r.URL.Path)
+
+		metrics.InFlightRequests.Inc(service)
+		defer metrics.InFlightRequests.Dec(service)
+
+		start := time.Now()
+		rr := &responseRecorder{w, http.StatusOK}
+		next.ServeHTTP(rr, r)
+		duration := time.Since(start).Seconds()
+
+		status := strconv.Itoa(rr.statusCode)
+		metrics.RequestsTotal.Inc(service, r.Method, status)
+		metrics.RequestDuration.Observe(duration, service, r.Method, status)
 	})
 }
 
@@ -96,37 +206,155 @@ func (rr *responseRecorder) WriteHeader(code int) {
 
 // ServiceMeshHandler handles incoming requests and routes them to the appropriate service
 type ServiceMeshHandler struct {
-	Registry *RegistryClient
-	Routes   map[string]string
-	Client   *http.Client
+	Discovery Discovery
+	Client    *http.Client
+
+	// Balancer is the default load-balancing strategy used when a route has
+	// no override in RouteBalancers.
+	Balancer Balancer
+	// RouteBalancers allows individual services to use a different strategy
+	// than the handler's default Balancer.
+	RouteBalancers map[string]Balancer
+	// Stats tracks per-endpoint latency/error/in-flight counters that
+	// load-aware balancers consult when picking an endpoint.
+	Stats *StatsRegistry
+	// HealthChecker, if set, actively probes backend endpoints; unhealthy
+	// endpoints are excluded from routing until they recover.
+	HealthChecker *HealthChecker
+	// Breakers tracks a circuit breaker per endpoint so repeated failures
+	// short-circuit to an immediate 503 instead of dialing the backend.
+	Breakers *CircuitBreakerRegistry
+	// Transports maps a Service's declared protocol to the Transport that
+	// proxies requests to it.
+	Transports *TransportRegistry
+	// Retry controls retry, hedging, and body-buffering behavior in forwardRequest.
+	Retry RetryConfig
+	// Events publishes a RouteEvent whenever the admin API changes the
+	// routing table, for SSE subscribers.
+	Events *RouteEventBroadcaster
+	// Metrics holds the Prometheus metrics exported via /metrics.
+	Metrics *MetricsRegistry
+	// TLSManager, if set, supplies the mTLS config transports should use
+	// when dialing "https"/"wss" upstreams directly instead of through
+	// Client (e.g. WebSocketTransport's raw TCP/TLS tunnel).
+	TLSManager *TLSManager
+
+	mu        sync.RWMutex
+	routes    map[string][]string
+	protocols map[string]string
+	// overrides holds routes set via the admin API (RegisterRoute,
+	// DrainRoute); UpdateRoutes re-applies them on top of Discovery's result
+	// so they survive the periodic refresh instead of being clobbered by it.
+	overrides map[string]routeOverride
+}
+
+// routeOverride is an admin-set routing entry that takes precedence over
+// whatever Discovery reports for the same service name.
+type routeOverride struct {
+	endpoints []string
+	protocol  string
 }
 
-// NewServiceMeshHandler creates a new ServiceMeshHandler
-func NewServiceMeshHandler(registry *RegistryClient) *ServiceMeshHandler {
+// NewServiceMeshHandler creates a new ServiceMeshHandler that discovers
+// services via discovery and proxies requests using transports.
+func NewServiceMeshHandler(discovery Discovery, transports *TransportRegistry) *ServiceMeshHandler {
 	return &ServiceMeshHandler{
-		Registry: registry,
-		Routes:   make(map[string]string),
+		Discovery:      discovery,
+		routes:         make(map[string][]string),
+		protocols:      make(map[string]string),
+		overrides:      make(map[string]routeOverride),
+		RouteBalancers: make(map[string]Balancer),
+		Balancer:       NewRoundRobinBalancer(),
+		Stats:          NewStatsRegistry(),
+		Breakers:       NewCircuitBreakerRegistry(5, 30*time.Second),
+		Transports:     transports,
+		Retry:          DefaultRetryConfig(),
+		Events:         NewRouteEventBroadcaster(),
+		Metrics:        NewMetricsRegistry(),
 		Client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
 }
 
-// UpdateRoutes fetches the latest services from the registry and updates the routing table
+// UpdateRoutes fetches the latest services from Discovery and updates the
+// routing table, then re-applies any admin-set overrides on top so runtime
+// registrations and drains survive the refresh.
 func (sm *ServiceMeshHandler) UpdateRoutes() error {
-	services, err := sm.Registry.GetServices()
+	services, err := sm.Discovery.GetServices()
 	if err != nil {
 		return err
 	}
 
-	newRoutes := make(map[string]string)
+	newRoutes := make(map[string][]string)
+	newProtocols := make(map[string]string)
 	for _, service := range services {
-		newRoutes[service.Name] = service.URL
+		newRoutes[service.Name] = service.Endpoints()
+		newProtocols[service.Name] = service.protocolOrDefault()
 	}
-	sm.Routes = newRoutes
+
+	sm.mu.Lock()
+	for name, override := range sm.overrides {
+		newRoutes[name] = override.endpoints
+		newProtocols[name] = override.protocol
+	}
+	sm.routes = newRoutes
+	sm.protocols = newProtocols
+	sm.mu.Unlock()
 	return nil
 }
 
+// protocolFor returns the declared protocol for serviceName, defaulting to
+// "http" if the service is unknown.
+func (sm *ServiceMeshHandler) protocolFor(serviceName string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if protocol, ok := sm.protocols[serviceName]; ok {
+		return protocol
+	}
+	return "http"
+}
+
+// endpointsFor returns the current healthy backend URLs registered for
+// serviceName. An endpoint ejected by the HealthChecker is omitted.
+func (sm *ServiceMeshHandler) endpointsFor(serviceName string) ([]string, bool) {
+	sm.mu.RLock()
+	all, exists := sm.routes[serviceName]
+	sm.mu.RUnlock()
+	if !exists || sm.HealthChecker == nil {
+		return all, exists
+	}
+
+	healthy := make([]string, 0, len(all))
+	for _, endpoint := range all {
+		if sm.HealthChecker.IsHealthy(endpoint) {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	return healthy, true
+}
+
+// AllEndpoints returns every backend URL currently known across all routes,
+// regardless of health, for use by the health checker's probe loop.
+func (sm *ServiceMeshHandler) AllEndpoints() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	var all []string
+	for _, endpoints := range sm.routes {
+		all = append(all, endpoints...)
+	}
+	return all
+}
+
+// balancerFor returns the load balancer to use for serviceName, preferring a
+// per-route override over the handler default.
+func (sm *ServiceMeshHandler) balancerFor(serviceName string) Balancer {
+	if b, ok := sm.RouteBalancers[serviceName]; ok {
+		return b
+	}
+	return sm.Balancer
+}
+
 // ServeHTTP routes the request to the appropriate service based on the URL path
 func (sm *ServiceMeshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Expected URL pattern: /serviceName/optional/path
@@ -137,12 +365,18 @@ func (sm *ServiceMeshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 
 	serviceName := pathParts[0]
-	targetURL, exists := sm.Routes[serviceName]
-	if !exists {
+	endpoints, exists := sm.endpointsFor(serviceName)
+	if !exists || len(endpoints) == 0 {
 		http.Error(w, "Service not found", http.StatusNotFound)
 		return
 	}
 
+	endpoint, err := sm.balancerFor(serviceName).Pick(serviceName, endpoints, sm.Stats)
+	if err != nil {
+		http.Error(w, "No healthy endpoints for service", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Construct the target URL
 	var targetPath string
 	if len(pathParts) > 1 {
@@ -151,44 +385,93 @@ func (sm *ServiceMeshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		targetPath = ""
 	}
 
-	fullURL := targetURL
+	fullURL := endpoint
 	if targetPath != "" {
-		fullURL = strings.TrimRight(targetURL, "/") + "/" + targetPath
+		fullURL = strings.TrimRight(endpoint, "/") + "/" + targetPath
+	}
+
+	protocol := sm.protocolFor(serviceName)
+	transport, ok := sm.Transports.Get(protocol)
+	if !ok {
+		http.Error(w, "No transport registered for protocol "+protocol, http.StatusInternalServerError)
+		return
 	}
 
-	// Forward the request
-	sm.forwardRequest(w, r, fullURL)
+	// Forward the request using the protocol-appropriate transport
+	transport.Forward(sm, w, r, endpoint, fullURL)
 }
 
-// forwardRequest forwards the incoming request to the target service with retry logic
-func (sm *ServiceMeshHandler) forwardRequest(w http.ResponseWriter, r *http.Request, target string) {
-	// Create a new request
-	req, err := http.NewRequest(r.Method, target, r.Body)
+// forwardRequest forwards the incoming request to the target service over
+// client, retrying idempotent requests with exponential backoff, optionally
+// hedging a slow attempt, and recording per-endpoint latency/error stats so
+// the balancer and circuit breaker can account for them on future picks.
+func (sm *ServiceMeshHandler) forwardRequest(w http.ResponseWriter, r *http.Request, endpoint, target string, client *http.Client) {
+	breaker := sm.Breakers.Get(endpoint)
+	if !breaker.Allow() {
+		http.Error(w, "Endpoint temporarily unavailable (circuit open)", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := prepareBody(r, sm.Retry.MaxBodyBytes)
 	if err != nil {
-		http.Error(w, "Failed to create request to target service", http.StatusInternalServerError)
+		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
 		return
 	}
+	retryable := body.replayable && isIdempotent(r.Method, r.Header)
 
-	// Copy headers
-	for key, values := range r.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
-		}
+	ctx := r.Context()
+	if sm.Retry.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sm.Retry.RequestTimeout)
+		defer cancel()
+	}
+
+	ctx, span := StartSpan(ctx, "forwardRequest")
+	span.SetAttribute("endpoint", endpoint)
+	span.SetAttribute("target", target)
+	defer span.End()
+
+	stats := sm.Stats.Get(endpoint)
+
+	maxAttempts := 1
+	if retryable {
+		maxAttempts = sm.Retry.MaxRetries
 	}
 
-	// Implement simple retry logic
-	maxRetries := 3
 	var resp *http.Response
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		resp, err = sm.Client.Do(req)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if retryable && sm.Retry.HedgeDelay > 0 {
+			resp, err = sm.doHedged(ctx, r, target, body, stats, client)
+		} else {
+			resp, err = sm.doOnce(ctx, r, target, body, stats, client)
+		}
+
 		if err == nil && resp.StatusCode < 500 {
 			break
 		}
-		log.Printf("Attempt %d: Failed to forward request to %s: %v", attempt, target, err)
-		time.Sleep(time.Duration(attempt) * time.Second) // Exponential backoff
+		if !retryable || ctx.Err() != nil {
+			break
+		}
+		logger.Warn("retrying forward request", "attempt", attempt, "target", target, "error", err)
+
+		select {
+		case <-time.After(backoffWithJitter(attempt, sm.Retry.BaseBackoff)):
+		case <-ctx.Done():
+		}
+	}
+
+	if err == nil && resp.StatusCode < 500 {
+		breaker.RecordSuccess()
+	} else {
+		breaker.RecordFailure()
+		sm.Metrics.UpstreamErrorsTotal.Inc(endpoint)
 	}
 
 	if err != nil {
+		if ctx.Err() != nil {
+			http.Error(w, "Request to target service timed out or was cancelled", http.StatusGatewayTimeout)
+			return
+		}
 		http.Error(w, "Failed to reach target service", http.StatusServiceUnavailable)
 		return
 	}
@@ -200,24 +483,103 @@ func (sm *ServiceMeshHandler) forwardRequest(w http.ResponseWriter, r *http.Requ
 			w.Header().Add(key, value)
 		}
 	}
+	// Pre-declare any trailer names so the client knows to expect them (this
+	// is how gRPC's grpc-status/grpc-message reach the caller, since gRPC
+	// sends its real status as HTTP/2 trailers rather than headers).
+	if len(resp.Trailer) > 0 {
+		names := make([]string, 0, len(resp.Trailer))
+		for key := range resp.Trailer {
+			names = append(names, key)
+		}
+		w.Header().Set("Trailer", strings.Join(names, ", "))
+	}
 	w.WriteHeader(resp.StatusCode)
 	io.Copy(w, resp.Body)
+	for key, values := range resp.Trailer {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+}
+
+// meshHealthHandler serves the current health-check and circuit-breaker
+// state of every known endpoint, for operator diagnostics.
+func (sm *ServiceMeshHandler) meshHealthHandler(w http.ResponseWriter, r *http.Request) {
+	type endpointStatus struct {
+		Healthy      bool   `json:"healthy"`
+		CircuitState string `json:"circuit_state"`
+	}
+
+	breakers := sm.Breakers.Snapshot()
+	status := make(map[string]endpointStatus)
+	for _, endpoint := range sm.AllEndpoints() {
+		healthy := true
+		if sm.HealthChecker != nil {
+			healthy = sm.HealthChecker.IsHealthy(endpoint)
+		}
+		circuitState := "closed"
+		if state, ok := breakers[endpoint]; ok {
+			circuitState = state
+		}
+		status[endpoint] = endpointStatus{Healthy: healthy, CircuitState: circuitState}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.Error("failed to encode mesh health response", "error", err)
+	}
 }
 
 func main() {
 	// Configuration
 	registryURL := "http://localhost:8081" // URL of the Cdaprod Registry
-	apiKey := "your-secure-api-key"        // Replace with a secure API key
 
 	// Initialize Registry Client
 	registryClient := NewRegistryClient(registryURL)
 
+	// Initialize the API key store. Swap NewStaticKeyStore for
+	// NewFileKeyStore, NewRegistryKeyStore, or NewExternalKeyStore to source
+	// keys from a file, the registry, or an external auth service.
+	keys := NewStaticKeyStore(APIKey{
+		Key:   "your-secure-api-key", // Replace with a secure, issued API key
+		Owner: "default",
+		RPS:   50,
+		Burst: 100,
+	})
+	rateLimiter := NewRateLimiter(DefaultRateLimitConfig())
+	quotas := NewQuotaTracker()
+
+	// Initialize TLS: SNI-based serving certificates, optional mTLS for
+	// inbound clients, and mTLS for "https://" upstreams.
+	tlsManager, err := NewTLSManager(TLSConfig{
+		Certs: []CertEntry{
+			{ServerName: "mesh.cdaprod.dev", CertFile: "/etc/cdaprod-mesh/tls/server.crt", KeyFile: "/etc/cdaprod-mesh/tls/server.key"},
+		},
+		ClientCAFile:     "/etc/cdaprod-mesh/tls/client-ca.crt",
+		AllowedClientCNs: []string{"cdaprod-mesh-client"},
+		UpstreamCAFile:   "/etc/cdaprod-mesh/tls/upstream-ca.crt",
+		UpstreamCertFile: "/etc/cdaprod-mesh/tls/upstream-client.crt",
+		UpstreamKeyFile:  "/etc/cdaprod-mesh/tls/upstream-client.key",
+	})
+	if err != nil {
+		logger.Error("failed to initialize TLS", "error", err)
+		os.Exit(1)
+	}
+	go tlsManager.Run(10 * time.Second)
+	defer tlsManager.Stop()
+
 	// Initialize Service Mesh Handler
-	serviceMesh := NewServiceMeshHandler(registryClient)
+	serviceMesh := NewServiceMeshHandler(registryClient, NewTransportRegistry())
+	serviceMesh.Client.Transport = tlsManager.UpstreamTransport()
+	serviceMesh.TLSManager = tlsManager
+	serviceMesh.HealthChecker = NewHealthChecker(DefaultHealthCheckConfig())
+	go serviceMesh.HealthChecker.Run(serviceMesh.AllEndpoints)
+	defer serviceMesh.HealthChecker.Stop()
 
 	// Initial route update
 	if err := serviceMesh.UpdateRoutes(); err != nil {
-		log.Fatalf("Failed to initialize service mesh routes: %v", err)
+		logger.Error("failed to initialize service mesh routes", "error", err)
+		os.Exit(1)
 	}
 
 	// Periodically update routes from the registry
@@ -226,27 +588,52 @@ func main() {
 	go func() {
 		for range ticker.C {
 			if err := serviceMesh.UpdateRoutes(); err != nil {
-				log.Printf("Failed to update routes: %v", err)
+				logger.Error("failed to update routes", "error", err)
 			} else {
-				log.Println("Service mesh routes updated successfully")
+				logger.Info("service mesh routes updated successfully")
 			}
 		}
 	}()
 
-	// Set up HTTP server with middleware
+	// Set up the proxy/admin HTTP server with middleware. /metrics is
+	// deliberately not mounted here: it's served on its own listener below so
+	// scraping it never has to go through API-key auth, rate limiting, or
+	// quota accounting meant for proxied traffic.
 	mux := http.NewServeMux()
-	mux.Handle("/", serviceMesh)
+	mux.HandleFunc("/mesh/health", serviceMesh.meshHealthHandler)
+	mux.HandleFunc("/mesh/routes", serviceMesh.routesHandler)
+	mux.HandleFunc("/mesh/routes/events", serviceMesh.routeEventsHandler)
+	mux.Handle("/", metricsMiddleware(serviceMesh, serviceMesh.Metrics))
 
-	// Apply middleware: Authentication and Logging
-	handler := loggingMiddleware(authMiddleware(mux, apiKey))
+	// Apply middleware: Authentication (with rate limiting and quotas), Logging, and Tracing
+	handler := tracingMiddleware(loggingMiddleware(authMiddleware(mux, keys, rateLimiter, quotas)))
 
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: handler,
+		Addr:      ":8443",
+		Handler:   handler,
+		TLSConfig: tlsManager.ServerTLSConfig(),
 	}
 
-	log.Printf("Cdaprod Service Mesh is running on %s", server.Addr)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	// /metrics is served plaintext on its own port so Prometheus can scrape it
+	// without an API key, mirroring the heapster pattern of mounting / and
+	// /metrics on distinct handlers.
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", serviceMesh.Metrics.Handler())
+	metricsServer := &http.Server{
+		Addr:    ":9090",
+		Handler: metricsMux,
 	}
-}
\ No newline at end of file
+	go func() {
+		logger.Info("metrics listener starting", "addr", metricsServer.Addr)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server failed", "error", err)
+		}
+	}()
+	defer metricsServer.Close()
+
+	logger.Info("Cdaprod Service Mesh is running", "addr", server.Addr)
+	if err := server.ListenAndServeTLS("", ""); err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
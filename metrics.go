@@ -0,0 +1,212 @@
+// metrics.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metricKey builds a stable key for a label set so it can be used as a map key.
+func metricKey(labels []string) string {
+	return strings.Join(labels, "\x1f")
+}
+
+// labeledCounter is a monotonically increasing counter split by label values.
+type labeledCounter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newLabeledCounter(name, help string, labels ...string) *labeledCounter {
+	return &labeledCounter{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// Inc increments the counter for the given label values, which must be
+// supplied in the same order as the labels the counter was created with.
+func (c *labeledCounter) Inc(labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[metricKey(labelValues)]++
+}
+
+func (c *labeledCounter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %v\n", c.name, labelString(c.labels, key), c.values[key])
+	}
+}
+
+// labeledGauge is a value that can go up or down, split by label values.
+type labeledGauge struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newLabeledGauge(name, help string, labels ...string) *labeledGauge {
+	return &labeledGauge{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// Inc increases the gauge for labelValues by 1.
+func (g *labeledGauge) Inc(labelValues ...string) { g.add(1, labelValues) }
+
+// Dec decreases the gauge for labelValues by 1.
+func (g *labeledGauge) Dec(labelValues ...string) { g.add(-1, labelValues) }
+
+func (g *labeledGauge) add(delta float64, labelValues []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[metricKey(labelValues)] += delta
+}
+
+func (g *labeledGauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range sortedKeys(g.values) {
+		fmt.Fprintf(w, "%s%s %v\n", g.name, labelString(g.labels, key), g.values[key])
+	}
+}
+
+// defaultLatencyBuckets are histogram bucket upper bounds, in seconds.
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labeledHistogram tracks a distribution of observed values, split by label values.
+type labeledHistogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	counts map[string][]uint64
+	sums   map[string]float64
+	totals map[string]uint64
+}
+
+func newLabeledHistogram(name, help string, buckets []float64, labels ...string) *labeledHistogram {
+	return &labeledHistogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		counts:  make(map[string][]uint64),
+		sums:    make(map[string]float64),
+		totals:  make(map[string]uint64),
+	}
+}
+
+// Observe records value (in seconds) for the given label values.
+func (h *labeledHistogram) Observe(value float64, labelValues ...string) {
+	key := metricKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets))
+		h.counts[key] = counts
+	}
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			counts[i]++
+		}
+	}
+	h.sums[key] += value
+	h.totals[key]++
+}
+
+func (h *labeledHistogram) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.sums) {
+		counts := h.counts[key]
+		for i, upperBound := range h.buckets {
+			le := strconv.FormatFloat(upperBound, 'g', -1, 64)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(append(append([]string{}, h.labels...), "le"), key+"\x1f"+le), counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(append(append([]string{}, h.labels...), "le"), key+"\x1f+Inf"), h.totals[key])
+		fmt.Fprintf(w, "%s_sum%s %v\n", h.name, labelString(h.labels, key), h.sums[key])
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelString(h.labels, key), h.totals[key])
+	}
+}
+
+// sortedKeys returns m's keys sorted, for deterministic /metrics output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelString renders a Prometheus label set ({name="value",...}) from
+// parallel label names and a "\x1f"-joined key of label values.
+func labelString(labels []string, key string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	values := strings.Split(key, "\x1f")
+	parts := make([]string, len(labels))
+	for i, name := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		parts[i] = fmt.Sprintf(`%s=%q`, name, value)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// MetricsRegistry holds every metric the mesh exports.
+type MetricsRegistry struct {
+	RequestsTotal       *labeledCounter
+	RequestDuration     *labeledHistogram
+	InFlightRequests    *labeledGauge
+	UpstreamErrorsTotal *labeledCounter
+}
+
+// NewMetricsRegistry creates a MetricsRegistry with all mesh metrics registered.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		RequestsTotal: newLabeledCounter(
+			"mesh_requests_total", "Total number of requests handled by the mesh.",
+			"service", "method", "status"),
+		RequestDuration: newLabeledHistogram(
+			"mesh_request_duration_seconds", "Request latency in seconds.", defaultLatencyBuckets,
+			"service", "method", "status"),
+		InFlightRequests: newLabeledGauge(
+			"mesh_in_flight_requests", "Number of requests currently being handled.",
+			"service"),
+		UpstreamErrorsTotal: newLabeledCounter(
+			"mesh_upstream_errors_total", "Total errors seen talking to a specific upstream endpoint.",
+			"upstream"),
+	}
+}
+
+// Handler serves every registered metric in Prometheus text exposition format.
+func (m *MetricsRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.RequestsTotal.write(w)
+		m.RequestDuration.write(w)
+		m.InFlightRequests.write(w)
+		m.UpstreamErrorsTotal.write(w)
+	})
+}
@@ -0,0 +1,195 @@
+// ratelimit.go
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a standard token-bucket limiter: tokens refill continuously
+// at refillRate per second up to capacity, and each allowed request consumes one.
+type tokenBucket struct {
+	capacity   float64
+	refillRate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a tokenBucket starting full, refilling at rps
+// tokens per second up to burst capacity.
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		capacity:   float64(burst),
+		refillRate: rps,
+		tokens:     float64(burst),
+		last:       time.Now(),
+	}
+}
+
+// Allow reports whether a request may proceed now. If not, it also returns
+// how long the caller should wait before the next token becomes available.
+func (b *tokenBucket) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.refillRate)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.refillRate <= 0 {
+		return false, time.Second
+	}
+	return false, time.Duration((1 - b.tokens) / b.refillRate * float64(time.Second))
+}
+
+// tokenBucketRegistry lazily creates one tokenBucket per key, each with its
+// own rate and burst, so a single registry can back many independently
+// limited keys or routes.
+type tokenBucketRegistry struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newTokenBucketRegistry() *tokenBucketRegistry {
+	return &tokenBucketRegistry{buckets: make(map[string]*tokenBucket)}
+}
+
+// get returns the bucket for key, creating it with rps/burst on first use.
+func (r *tokenBucketRegistry) get(key string, rps float64, burst int) *tokenBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.buckets[key]
+	if !ok {
+		b = newTokenBucket(rps, burst)
+		r.buckets[key] = b
+	}
+	return b
+}
+
+// RateLimitConfig controls the mesh-wide and per-route token buckets. Each
+// API key additionally carries its own RPS/Burst in its APIKey record.
+type RateLimitConfig struct {
+	// GlobalRPS and GlobalBurst bound the request rate across all keys and
+	// routes combined, protecting the mesh itself from being overwhelmed.
+	GlobalRPS   float64
+	GlobalBurst int
+	// RouteRPS and RouteBurst bound the request rate per destination
+	// service, regardless of which key is calling it.
+	RouteRPS   float64
+	RouteBurst int
+}
+
+// DefaultRateLimitConfig returns generous mesh-wide defaults that only kick
+// in under genuine overload; per-key limits are expected to bind first.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		GlobalRPS:   1000,
+		GlobalBurst: 2000,
+		RouteRPS:    200,
+		RouteBurst:  400,
+	}
+}
+
+// RateLimiter enforces three layers of token-bucket limits for a request:
+// a mesh-wide global bucket, a per-API-key bucket (sized from the key's own
+// RPS/Burst), and a per-route bucket shared by all keys calling a service.
+type RateLimiter struct {
+	config RateLimitConfig
+
+	global   *tokenBucket
+	perKey   *tokenBucketRegistry
+	perRoute *tokenBucketRegistry
+}
+
+// NewRateLimiter creates a RateLimiter using config for the global and
+// per-route buckets.
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:   config,
+		global:   newTokenBucket(config.GlobalRPS, config.GlobalBurst),
+		perKey:   newTokenBucketRegistry(),
+		perRoute: newTokenBucketRegistry(),
+	}
+}
+
+// Allow reports whether a request from apiKey to service may proceed,
+// checking the global, per-key, and per-route buckets in that order. If any
+// layer is exhausted, it returns the longest of the Retry-After durations
+// seen so far.
+func (rl *RateLimiter) Allow(apiKey APIKey, service string) (bool, time.Duration) {
+	if ok, retryAfter := rl.global.Allow(); !ok {
+		return false, retryAfter
+	}
+
+	if apiKey.RPS > 0 {
+		burst := apiKey.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(apiKey.RPS))
+		}
+		if ok, retryAfter := rl.perKey.get(apiKey.Key, apiKey.RPS, burst).Allow(); !ok {
+			return false, retryAfter
+		}
+	}
+
+	if rl.config.RouteRPS > 0 {
+		if ok, retryAfter := rl.perRoute.get(service, rl.config.RouteRPS, rl.config.RouteBurst).Allow(); !ok {
+			return false, retryAfter
+		}
+	}
+
+	return true, 0
+}
+
+// monthlyUsage tracks one API key's request count within a calendar month.
+type monthlyUsage struct {
+	month string // "2006-01"
+	count int64
+}
+
+// QuotaTracker enforces each API key's MonthlyQuota, resetting the count at
+// the start of every calendar month.
+type QuotaTracker struct {
+	mu    sync.Mutex
+	usage map[string]monthlyUsage
+}
+
+// NewQuotaTracker creates an empty QuotaTracker.
+func NewQuotaTracker() *QuotaTracker {
+	return &QuotaTracker{usage: make(map[string]monthlyUsage)}
+}
+
+// Allow reports whether apiKey has remaining quota for the current month
+// and, if so, counts this request against it. A MonthlyQuota of zero means
+// unlimited.
+func (q *QuotaTracker) Allow(apiKey APIKey) bool {
+	if apiKey.MonthlyQuota <= 0 {
+		return true
+	}
+
+	month := time.Now().Format("2006-01")
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.usage[apiKey.Key]
+	if u.month != month {
+		u = monthlyUsage{month: month}
+	}
+	if u.count >= apiKey.MonthlyQuota {
+		q.usage[apiKey.Key] = u
+		return false
+	}
+	u.count++
+	q.usage[apiKey.Key] = u
+	return true
+}
@@ -0,0 +1,93 @@
+// ratelimit_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketExhaustsBurstThenRefills(t *testing.T) {
+	b := newTokenBucket(10, 2)
+
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("first request should be allowed from a full bucket")
+	}
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("second request should be allowed (burst of 2)")
+	}
+	ok, retryAfter := b.Allow()
+	if ok {
+		t.Fatal("third immediate request should be rejected once burst is exhausted")
+	}
+	if retryAfter <= 0 {
+		t.Fatalf("retryAfter = %v, want > 0", retryAfter)
+	}
+
+	time.Sleep(retryAfter)
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("request after retryAfter has elapsed should be allowed")
+	}
+}
+
+func TestTokenBucketRegistryIsolatesKeys(t *testing.T) {
+	reg := newTokenBucketRegistry()
+	a := reg.get("a", 10, 1)
+	b := reg.get("b", 10, 1)
+
+	if a == b {
+		t.Fatal("distinct keys should get distinct buckets")
+	}
+	if reg.get("a", 10, 1) != a {
+		t.Fatal("the same key should reuse its existing bucket")
+	}
+}
+
+func TestRateLimiterEnforcesPerKeyLimit(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{GlobalRPS: 1000, GlobalBurst: 1000, RouteRPS: 1000, RouteBurst: 1000})
+	key := APIKey{Key: "k1", RPS: 1, Burst: 1}
+
+	if ok, _ := rl.Allow(key, "svc"); !ok {
+		t.Fatal("first request should be allowed")
+	}
+	if ok, _ := rl.Allow(key, "svc"); ok {
+		t.Fatal("second immediate request should be rejected by the per-key bucket")
+	}
+}
+
+func TestRateLimiterEnforcesGlobalLimitAcrossKeys(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{GlobalRPS: 1, GlobalBurst: 1, RouteRPS: 1000, RouteBurst: 1000})
+	keyA := APIKey{Key: "a", RPS: 1000, Burst: 1000}
+	keyB := APIKey{Key: "b", RPS: 1000, Burst: 1000}
+
+	if ok, _ := rl.Allow(keyA, "svc"); !ok {
+		t.Fatal("first request should be allowed")
+	}
+	if ok, _ := rl.Allow(keyB, "svc"); ok {
+		t.Fatal("a different key should still be blocked once the global bucket is exhausted")
+	}
+}
+
+func TestQuotaTrackerEnforcesMonthlyLimit(t *testing.T) {
+	q := NewQuotaTracker()
+	key := APIKey{Key: "k1", MonthlyQuota: 2}
+
+	if !q.Allow(key) {
+		t.Fatal("first request should be within quota")
+	}
+	if !q.Allow(key) {
+		t.Fatal("second request should be within quota")
+	}
+	if q.Allow(key) {
+		t.Fatal("third request should exceed the monthly quota")
+	}
+}
+
+func TestQuotaTrackerZeroQuotaIsUnlimited(t *testing.T) {
+	q := NewQuotaTracker()
+	key := APIKey{Key: "k1", MonthlyQuota: 0}
+	for i := 0; i < 5; i++ {
+		if !q.Allow(key) {
+			t.Fatalf("request %d should be allowed when MonthlyQuota is 0 (unlimited)", i)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// retry.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryConfig controls how forwardRequest retries and hedges upstream calls.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of attempts for idempotent requests.
+	MaxRetries int
+	// MaxBodyBytes is the largest request body forwardRequest will buffer in
+	// memory to make it replayable across retries. Bodies larger than this
+	// are streamed once and never retried.
+	MaxBodyBytes int64
+	// RequestTimeout bounds the whole forwardRequest call, including all
+	// retries and any hedged attempt. Zero means no additional deadline
+	// beyond the inbound request's own context.
+	RequestTimeout time.Duration
+	// BaseBackoff is the starting delay for exponential backoff between
+	// retries; actual delay is randomized around it.
+	BaseBackoff time.Duration
+	// HedgeDelay, if positive, causes forwardRequest to fire a second,
+	// identical request if the first hasn't responded within this delay,
+	// returning whichever response arrives first and cancelling the other.
+	HedgeDelay time.Duration
+}
+
+// DefaultRetryConfig returns conservative defaults: three attempts for
+// idempotent requests, a 1MB replay buffer, and no hedging.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     3,
+		MaxBodyBytes:   1 << 20,
+		RequestTimeout: 10 * time.Second,
+		BaseBackoff:    100 * time.Millisecond,
+		HedgeDelay:     0,
+	}
+}
+
+// isIdempotent reports whether a request is safe to retry automatically:
+// either its method is inherently idempotent, or the caller has opted in
+// with an Idempotency-Key header.
+func isIdempotent(method string, header http.Header) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return header.Get("Idempotency-Key") != ""
+}
+
+// preparedBody is the result of buffering an inbound request body so it can
+// be replayed across retries.
+type preparedBody struct {
+	// bytes holds the fully-buffered body, reusable across any number of attempts.
+	bytes []byte
+	// fallback holds a body that can only be read once, used when the body
+	// exceeded the buffering limit.
+	fallback io.Reader
+	// replayable is true when bytes can be safely resent on every attempt.
+	replayable bool
+}
+
+// prepareBody buffers up to limit bytes of r's body so it can be replayed
+// across retries. Bodies larger than limit are left as a single-use stream
+// reconstructed from the bytes already read plus the remainder of r.Body.
+func prepareBody(r *http.Request, limit int64) (preparedBody, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return preparedBody{replayable: true}, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return preparedBody{}, err
+	}
+	if int64(len(data)) <= limit {
+		return preparedBody{bytes: data, replayable: true}, nil
+	}
+	return preparedBody{fallback: io.MultiReader(bytes.NewReader(data), r.Body)}, nil
+}
+
+// reader returns an io.Reader for one attempt's request body.
+func (b preparedBody) reader() io.Reader {
+	if b.replayable {
+		if b.bytes == nil {
+			return nil
+		}
+		return bytes.NewReader(b.bytes)
+	}
+	return b.fallback
+}
+
+// backoffWithJitter returns a randomized exponential backoff duration for
+// the given (1-indexed) attempt number.
+func backoffWithJitter(attempt int, base time.Duration) time.Duration {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	backoff := base * time.Duration(uint(1)<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+	return backoff/2 + jitter
+}
+
+// buildUpstreamRequest constructs the outgoing request for one attempt,
+// bound to ctx and carrying a copy of the original headers.
+func buildUpstreamRequest(ctx context.Context, r *http.Request, target string, body preparedBody) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, body.reader())
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	req.ContentLength = r.ContentLength
+	return req, nil
+}
+
+// doOnce issues a single attempt against target over client, recording
+// latency/error stats for the backend endpoint.
+func (sm *ServiceMeshHandler) doOnce(ctx context.Context, r *http.Request, target string, body preparedBody, stats *EndpointStats, client *http.Client) (*http.Response, error) {
+	req, err := buildUpstreamRequest(ctx, r, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Start()
+	start := time.Now()
+	resp, err := client.Do(req)
+	stats.Done(time.Since(start), err)
+	return resp, err
+}
+
+// hedgeResult carries one doOnce attempt's outcome back to doHedged, along
+// with that attempt's own cancel func and the other attempt's, so whichever
+// result wins can abort its rival without touching its own (still in use)
+// context.
+type hedgeResult struct {
+	resp        *http.Response
+	err         error
+	cancel      context.CancelFunc
+	otherCancel context.CancelFunc
+}
+
+// cancelOnCloseBody wraps a response body so cancel runs once the body is
+// fully read and closed, instead of the instant the attempt "wins" a hedge
+// race. Cancelling the winning attempt's context any earlier would abort its
+// own still-in-flight body read out from under forwardRequest's io.Copy.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// doHedged issues a primary attempt and, if it hasn't completed within
+// sm.Retry.HedgeDelay, fires a second identical attempt. Whichever response
+// arrives first is returned, with its response body wrapped so the losing
+// attempt is cancelled immediately but the winner's own context isn't
+// cancelled until the caller finishes reading and closes the body. The
+// loser's response, once it eventually resolves, is drained and closed in
+// the background so it never leaks a connection.
+func (sm *ServiceMeshHandler) doHedged(ctx context.Context, r *http.Request, target string, body preparedBody, stats *EndpointStats, client *http.Client) (*http.Response, error) {
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	hedgeCtx, cancelHedge := context.WithCancel(ctx)
+
+	results := make(chan hedgeResult, 2)
+	run := func(attemptCtx context.Context, cancel, otherCancel context.CancelFunc) {
+		resp, err := sm.doOnce(attemptCtx, r, target, body, stats, client)
+		results <- hedgeResult{resp, err, cancel, otherCancel}
+	}
+
+	go run(primaryCtx, cancelPrimary, cancelHedge)
+	launched := 1
+
+	timer := time.NewTimer(sm.Retry.HedgeDelay)
+	defer timer.Stop()
+
+	select {
+	case res := <-results:
+		return finishHedge(res, launched-1, results)
+	case <-timer.C:
+		go run(hedgeCtx, cancelHedge, cancelPrimary)
+		launched++
+	case <-ctx.Done():
+		cancelPrimary()
+		cancelHedge()
+		go drainHedgeResults(results, launched)
+		return nil, ctx.Err()
+	}
+
+	select {
+	case res := <-results:
+		return finishHedge(res, launched-1, results)
+	case <-ctx.Done():
+		cancelPrimary()
+		cancelHedge()
+		go drainHedgeResults(results, launched)
+		return nil, ctx.Err()
+	}
+}
+
+// finishHedge aborts the losing attempt's context, defers the winning
+// attempt's own cancellation until its response body is closed, and drains
+// the remaining outstanding results (if any) in the background.
+func finishHedge(res hedgeResult, stillOutstanding int, results <-chan hedgeResult) (*http.Response, error) {
+	res.otherCancel()
+
+	if res.resp != nil {
+		res.resp.Body = &cancelOnCloseBody{ReadCloser: res.resp.Body, cancel: res.cancel}
+	} else {
+		res.cancel()
+	}
+
+	if stillOutstanding > 0 {
+		go drainHedgeResults(results, stillOutstanding)
+	}
+	return res.resp, res.err
+}
+
+// drainHedgeResults reads n still-outstanding hedgeResults, closing any
+// response body that arrives for an attempt doHedged already walked away
+// from, so it doesn't leak the underlying connection.
+func drainHedgeResults(results <-chan hedgeResult, n int) {
+	for i := 0; i < n; i++ {
+		res := <-results
+		if res.resp != nil {
+			io.Copy(io.Discard, res.resp.Body)
+			res.resp.Body.Close()
+		}
+	}
+}
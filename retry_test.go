@@ -0,0 +1,168 @@
+// retry_test.go
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsIdempotent(t *testing.T) {
+	cases := []struct {
+		method string
+		header http.Header
+		want   bool
+	}{
+		{http.MethodGet, http.Header{}, true},
+		{http.MethodHead, http.Header{}, true},
+		{http.MethodPut, http.Header{}, true},
+		{http.MethodDelete, http.Header{}, true},
+		{http.MethodPost, http.Header{}, false},
+		{http.MethodPost, http.Header{"Idempotency-Key": []string{"abc"}}, true},
+	}
+	for _, c := range cases {
+		if got := isIdempotent(c.method, c.header); got != c.want {
+			t.Errorf("isIdempotent(%q, %v) = %v, want %v", c.method, c.header, got, c.want)
+		}
+	}
+}
+
+func TestPrepareBodyReplayableWithinLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	body, err := prepareBody(req, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !body.replayable {
+		t.Fatal("body should be replayable within the limit")
+	}
+
+	first, err := io.ReadAll(body.reader())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := io.ReadAll(body.reader())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(first) != "hello" || string(second) != "hello" {
+		t.Fatalf("got %q and %q, want both %q", first, second, "hello")
+	}
+}
+
+func TestPrepareBodyFallsBackOverLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+	body, err := prepareBody(req, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body.replayable {
+		t.Fatal("body over the limit should not be marked replayable")
+	}
+
+	got, err := io.ReadAll(body.reader())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("got %q, want %q", got, "hello world")
+	}
+}
+
+func TestBackoffWithJitterGrowsWithAttempt(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 1; attempt <= 4; attempt++ {
+		d := backoffWithJitter(attempt, base)
+		if d <= 0 {
+			t.Fatalf("attempt %d: backoff = %v, want > 0", attempt, d)
+		}
+	}
+	// attempt 4's backoff window (base*8, half to full) starts strictly above
+	// attempt 1's window (base*1, half to full) ends, so repeated sampling
+	// should never produce an attempt-4 delay smaller than attempt-1's max.
+	maxAttempt1 := base
+	for i := 0; i < 100; i++ {
+		if d1 := backoffWithJitter(1, base); d1 > maxAttempt1 {
+			t.Fatalf("attempt 1 backoff = %v, want <= %v", d1, maxAttempt1)
+		}
+		if d4 := backoffWithJitter(4, base); d4 <= maxAttempt1 {
+			t.Fatalf("expected backoff windows to grow: attempt1 max=%v, attempt4 sample=%v", maxAttempt1, d4)
+		}
+	}
+}
+
+// TestDoHedgedDoesNotTruncateTheWinningBody reproduces a bug where the
+// winning attempt's own context (used to build its *http.Request) was
+// cancelled the instant doHedged returned, aborting the caller's still
+// in-flight resp.Body read for any response that streams its body across
+// multiple writes - including the common fast path where the hedge never
+// even fires.
+func TestDoHedgedDoesNotTruncateTheWinningBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "chunk1-")
+		flusher.Flush()
+		time.Sleep(30 * time.Millisecond)
+		io.WriteString(w, "chunk2")
+	}))
+	defer server.Close()
+
+	sm := &ServiceMeshHandler{Retry: RetryConfig{HedgeDelay: 200 * time.Millisecond}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	stats := &EndpointStats{}
+
+	resp, err := sm.doHedged(context.Background(), req, server.URL, preparedBody{replayable: true}, stats, server.Client())
+	if err != nil {
+		t.Fatalf("doHedged returned an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the response body failed (likely truncated by a premature context cancel): %v", err)
+	}
+	if string(got) != "chunk1-chunk2" {
+		t.Fatalf("got body %q, want %q", got, "chunk1-chunk2")
+	}
+}
+
+// TestDoHedgedCancelsTheLosingAttempt checks that when the hedge actually
+// fires and wins the race, the slower primary attempt's context is
+// cancelled rather than left to run to completion.
+func TestDoHedgedCancelsTheLosingAttempt(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			// The primary attempt: never respond, so ctx cancellation is the
+			// only way this handler's request ever completes.
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "hedge-won")
+	}))
+	defer server.Close()
+
+	sm := &ServiceMeshHandler{Retry: RetryConfig{HedgeDelay: 10 * time.Millisecond}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	stats := &EndpointStats{}
+
+	resp, err := sm.doHedged(context.Background(), req, server.URL, preparedBody{replayable: true}, stats, server.Client())
+	if err != nil {
+		t.Fatalf("doHedged returned an error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading hedge winner's body: %v", err)
+	}
+	if string(got) != "hedge-won" {
+		t.Fatalf("got body %q, want %q", got, "hedge-won")
+	}
+}
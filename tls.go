@@ -0,0 +1,400 @@
+// tls.go
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// CertEntry pairs a certificate with the SNI server name it should be
+// served for.
+type CertEntry struct {
+	ServerName string
+	CertFile   string
+	KeyFile    string
+}
+
+// CertReloader holds the mesh's serving certificates, keyed by SNI server
+// name, so TLSManager can pick one per handshake and reload all of them
+// from disk without affecting connections already established.
+type CertReloader struct {
+	entries []CertEntry
+
+	mu       sync.RWMutex
+	certs    map[string]*tls.Certificate
+	fallback *tls.Certificate // served when SNI is absent or matches nothing
+}
+
+// NewCertReloader creates a CertReloader and loads every entry immediately.
+func NewCertReloader(entries []CertEntry) (*CertReloader, error) {
+	r := &CertReloader{entries: entries}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads every certificate/key pair and replaces the in-memory set.
+func (r *CertReloader) Reload() error {
+	certs := make(map[string]*tls.Certificate, len(r.entries))
+	var fallback *tls.Certificate
+	for _, e := range r.entries {
+		cert, err := tls.LoadX509KeyPair(e.CertFile, e.KeyFile)
+		if err != nil {
+			return fmt.Errorf("loading certificate for %q: %w", e.ServerName, err)
+		}
+		certs[e.ServerName] = &cert
+		if fallback == nil {
+			fallback = &cert
+		}
+	}
+
+	r.mu.Lock()
+	r.certs = certs
+	r.fallback = fallback
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate, selecting a serving
+// certificate by the client's SNI server name and falling back to the first
+// configured entry if the name is absent or unrecognized.
+func (r *CertReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cert, ok := r.certs[hello.ServerName]; ok {
+		return cert, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("no certificate configured for server name %q", hello.ServerName)
+}
+
+// AllowedClientCNs restricts mTLS connections to client certificates whose
+// verified Common Name appears in the list, the same allow-list pattern
+// used elsewhere in the Cdaprod stack to gate access by client identity. An
+// empty list accepts any certificate that chains to the configured CA bundle.
+type AllowedClientCNs []string
+
+// verifyPeerCertificate is installed as tls.Config.VerifyPeerCertificate; by
+// the time it runs, the standard library has already verified the chain
+// against ClientCAs, so it only needs to check the identity.
+func (allowed AllowedClientCNs) verifyPeerCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return fmt.Errorf("no verified client certificate")
+	}
+
+	cn := verifiedChains[0][0].Subject.CommonName
+	for _, a := range allowed {
+		if a == cn {
+			return nil
+		}
+	}
+	return fmt.Errorf("client certificate CN %q is not in the allowed list", cn)
+}
+
+// UpstreamTLS holds the mTLS configuration the mesh uses when dialing
+// upstream services registered with an "https://" URL: a CA bundle to
+// verify the upstream's server certificate, and a client certificate to
+// present for mutual TLS.
+type UpstreamTLS struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	mu     sync.RWMutex
+	config *tls.Config
+}
+
+// NewUpstreamTLS creates an UpstreamTLS and loads its certificates immediately.
+func NewUpstreamTLS(caFile, certFile, keyFile string) (*UpstreamTLS, error) {
+	u := &UpstreamTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	if err := u.Reload(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Reload re-reads the CA bundle and client certificate from disk.
+func (u *UpstreamTLS) Reload() error {
+	caData, err := os.ReadFile(u.CAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return fmt.Errorf("no valid certificates found in %s", u.CAFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(u.CertFile, u.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	u.mu.Lock()
+	u.config = config
+	u.mu.Unlock()
+	return nil
+}
+
+// clientConfig returns the mTLS config to use for a new dial, reflecting
+// the most recent Reload.
+func (u *UpstreamTLS) clientConfig() *tls.Config {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.config
+}
+
+// Transport builds an http.Transport that dials upstream services with the
+// current mTLS configuration, re-read on every dial so Reload takes effect
+// immediately without recreating the Transport or dropping connections
+// already in flight.
+func (u *UpstreamTLS) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer := &tls.Dialer{Config: u.clientConfig()}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return t
+}
+
+// TLSConfig configures the mesh's serving certificates, inbound mTLS
+// client-certificate policy, and outbound mTLS to upstream services.
+type TLSConfig struct {
+	// Certs are the serving certificates selected by SNI; the first entry
+	// is also used as the fallback for clients that send no SNI name.
+	Certs []CertEntry
+	// ClientCAFile, if set, enables mTLS on the listener: client certs are
+	// required and must chain to a CA in this bundle.
+	ClientCAFile string
+	// AllowedClientCNs, if non-empty, further restricts accepted client
+	// certificates to these Common Names.
+	AllowedClientCNs []string
+	// UpstreamCAFile, UpstreamCertFile, and UpstreamKeyFile configure mTLS
+	// to upstream services registered with an "https://" URL. Leave
+	// UpstreamCAFile empty to dial upstreams with the default transport.
+	UpstreamCAFile   string
+	UpstreamCertFile string
+	UpstreamKeyFile  string
+}
+
+// TLSManager owns the mesh's serving certificates, client CA bundle, and
+// upstream mTLS configuration, and keeps all three in sync with their
+// source files.
+type TLSManager struct {
+	config   TLSConfig
+	certs    *CertReloader
+	upstream *UpstreamTLS
+
+	mu        sync.RWMutex
+	clientCAs *x509.CertPool
+
+	stop chan struct{}
+}
+
+// NewTLSManager creates a TLSManager and loads every configured certificate,
+// key, and CA bundle immediately.
+func NewTLSManager(config TLSConfig) (*TLSManager, error) {
+	certs, err := NewCertReloader(config.Certs)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &TLSManager{config: config, certs: certs, stop: make(chan struct{})}
+
+	if config.ClientCAFile != "" {
+		if err := m.reloadClientCAs(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.UpstreamCAFile != "" {
+		upstream, err := NewUpstreamTLS(config.UpstreamCAFile, config.UpstreamCertFile, config.UpstreamKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		m.upstream = upstream
+	}
+
+	return m, nil
+}
+
+func (m *TLSManager) reloadClientCAs() error {
+	data, err := os.ReadFile(m.config.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no valid certificates found in %s", m.config.ClientCAFile)
+	}
+
+	m.mu.Lock()
+	m.clientCAs = pool
+	m.mu.Unlock()
+	return nil
+}
+
+// ServerTLSConfig builds the *tls.Config for the mesh's listener:
+// SNI-based certificate selection via CertReloader, and, when ClientCAFile
+// is set, mutual TLS with an optional Common Name allow-list.
+func (m *TLSManager) ServerTLSConfig() *tls.Config {
+	config := &tls.Config{GetCertificate: m.certs.GetCertificate}
+	if m.config.ClientCAFile == "" {
+		return config
+	}
+
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	allowed := AllowedClientCNs(m.config.AllowedClientCNs)
+
+	// GetConfigForClient is invoked per-handshake, so it always sees the
+	// client CA pool as of the most recent Reload.
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		m.mu.RLock()
+		clientCAs := m.clientCAs
+		m.mu.RUnlock()
+
+		perConn := config.Clone()
+		perConn.ClientCAs = clientCAs
+		perConn.VerifyPeerCertificate = allowed.verifyPeerCertificate
+		return perConn, nil
+	}
+	return config
+}
+
+// UpstreamTransport returns the http.Transport the mesh should use to dial
+// upstream services, configured for mTLS if UpstreamCAFile was set, or the
+// default transport otherwise.
+func (m *TLSManager) UpstreamTransport() *http.Transport {
+	if m.upstream == nil {
+		return http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return m.upstream.Transport()
+}
+
+// UpstreamTLSConfig returns the *tls.Config to use for a one-off TLS dial to
+// an upstream at host (e.g. a "wss://" WebSocket backend), reflecting the
+// most recent Reload. If UpstreamCAFile wasn't configured, it returns a bare
+// config that verifies against the system root pool.
+func (m *TLSManager) UpstreamTLSConfig(host string) *tls.Config {
+	if m.upstream == nil {
+		return &tls.Config{ServerName: host}
+	}
+	config := m.upstream.clientConfig().Clone()
+	config.ServerName = host
+	return config
+}
+
+// Reload re-reads every configured serving certificate, client CA bundle,
+// and upstream mTLS credential from disk.
+func (m *TLSManager) Reload() error {
+	if err := m.certs.Reload(); err != nil {
+		return fmt.Errorf("reloading serving certificates: %w", err)
+	}
+	if m.config.ClientCAFile != "" {
+		if err := m.reloadClientCAs(); err != nil {
+			return fmt.Errorf("reloading client CA bundle: %w", err)
+		}
+	}
+	if m.upstream != nil {
+		if err := m.upstream.Reload(); err != nil {
+			return fmt.Errorf("reloading upstream mTLS config: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run reloads all certificates whenever SIGHUP is received or any
+// configured file's modification time advances, until Stop is called.
+// Existing connections are unaffected: Go's TLS stack consults
+// GetCertificate/GetConfigForClient per handshake, so only new connections
+// observe the change. Intended to be launched with `go`.
+func (m *TLSManager) Run(pollInterval time.Duration) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastMod := m.fileModTimes()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-sighup:
+			m.reloadAndLog("SIGHUP")
+		case <-ticker.C:
+			modTimes := m.fileModTimes()
+			if !modTimesEqual(lastMod, modTimes) {
+				m.reloadAndLog("file change")
+				lastMod = modTimes
+			}
+		}
+	}
+}
+
+// Stop terminates the TLSManager's reload-watch loop.
+func (m *TLSManager) Stop() {
+	close(m.stop)
+}
+
+func (m *TLSManager) reloadAndLog(trigger string) {
+	if err := m.Reload(); err != nil {
+		logger.Warn("failed to reload TLS configuration", "trigger", trigger, "error", err)
+		return
+	}
+	logger.Info("reloaded TLS configuration", "trigger", trigger)
+}
+
+// fileModTimes stats every file the TLSManager was configured with, for
+// change detection by Run.
+func (m *TLSManager) fileModTimes() map[string]time.Time {
+	paths := []string{m.config.ClientCAFile, m.config.UpstreamCAFile, m.config.UpstreamCertFile, m.config.UpstreamKeyFile}
+	for _, e := range m.config.Certs {
+		paths = append(paths, e.CertFile, e.KeyFile)
+	}
+
+	out := make(map[string]time.Time, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if info, err := os.Stat(p); err == nil {
+			out[p] = info.ModTime()
+		}
+	}
+	return out
+}
+
+// modTimesEqual reports whether two file-path-to-mtime snapshots match.
+func modTimesEqual(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, t := range a {
+		if !b[path].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
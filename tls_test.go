@@ -0,0 +1,65 @@
+// tls_test.go
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+)
+
+func TestModTimesEqual(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Second)
+
+	cases := []struct {
+		name string
+		a, b map[string]time.Time
+		want bool
+	}{
+		{"both empty", map[string]time.Time{}, map[string]time.Time{}, true},
+		{"identical", map[string]time.Time{"a": now}, map[string]time.Time{"a": now}, true},
+		{"different mtime", map[string]time.Time{"a": now}, map[string]time.Time{"a": later}, false},
+		{"different length", map[string]time.Time{"a": now}, map[string]time.Time{"a": now, "b": now}, false},
+		{"different path", map[string]time.Time{"a": now}, map[string]time.Time{"b": now}, false},
+	}
+	for _, c := range cases {
+		if got := modTimesEqual(c.a, c.b); got != c.want {
+			t.Errorf("%s: modTimesEqual() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAllowedClientCNsEmptyAllowsAnyVerifiedChain(t *testing.T) {
+	allowed := AllowedClientCNs(nil)
+	if err := allowed.verifyPeerCertificate(nil, nil); err != nil {
+		t.Fatalf("empty allow-list should accept any chain, got: %v", err)
+	}
+}
+
+func TestAllowedClientCNsRejectsMissingChain(t *testing.T) {
+	allowed := AllowedClientCNs{"trusted-client"}
+	if err := allowed.verifyPeerCertificate(nil, nil); err == nil {
+		t.Fatal("expected an error when no verified chain is present")
+	}
+}
+
+func TestAllowedClientCNsMatchesByCommonName(t *testing.T) {
+	allowed := AllowedClientCNs{"trusted-client"}
+	chain := [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "trusted-client"}},
+	}}
+	if err := allowed.verifyPeerCertificate(nil, chain); err != nil {
+		t.Fatalf("expected the matching CN to be allowed, got: %v", err)
+	}
+}
+
+func TestAllowedClientCNsRejectsUnlistedCommonName(t *testing.T) {
+	allowed := AllowedClientCNs{"trusted-client"}
+	chain := [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "someone-else"}},
+	}}
+	if err := allowed.verifyPeerCertificate(nil, chain); err == nil {
+		t.Fatal("expected an unlisted CN to be rejected")
+	}
+}
@@ -0,0 +1,127 @@
+// tracing.go
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// traceContextKey is the context key under which the active trace/span IDs
+// are stored.
+type traceContextKey struct{}
+
+// traceContext carries the W3C trace identifiers for the current request.
+type traceContext struct {
+	traceID string
+	spanID  string
+}
+
+// newID returns n random bytes hex-encoded, used for trace and span IDs.
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand read failures are effectively unrecoverable on any
+		// supported platform; fall back to a fixed, clearly-invalid ID
+		// rather than panicking the request path.
+		return hex.EncodeToString(make([]byte, n))
+	}
+	return hex.EncodeToString(b)
+}
+
+// newTraceID generates a new 16-byte W3C trace ID.
+func newTraceID() string { return newID(16) }
+
+// newSpanID generates a new 8-byte W3C span ID.
+func newSpanID() string { return newID(8) }
+
+// parseTraceparent extracts the trace ID from a W3C "traceparent" header
+// value ("00-<trace-id>-<span-id>-<flags>"). It reports ok=false if header
+// is empty or malformed.
+func parseTraceparent(header string) (traceID string, ok bool) {
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return "", false
+	}
+	traceID = header[3:35]
+	if _, err := hex.DecodeString(traceID); err != nil {
+		return "", false
+	}
+	return traceID, true
+}
+
+// formatTraceparent builds a W3C "traceparent" header value for traceID and spanID.
+func formatTraceparent(traceID, spanID string) string {
+	return fmt.Sprintf("00-%s-%s-01", traceID, spanID)
+}
+
+// withIngressTrace ensures r carries a traceparent header, generating a new
+// trace ID if the caller didn't supply one, and returns a context carrying
+// the resulting trace/span IDs for Span to pick up.
+func withIngressTrace(r *http.Request) (*http.Request, context.Context) {
+	traceID, ok := parseTraceparent(r.Header.Get("traceparent"))
+	if !ok {
+		traceID = newTraceID()
+	}
+	spanID := newSpanID()
+	r.Header.Set("traceparent", formatTraceparent(traceID, spanID))
+	r.Header.Set("X-Request-ID", traceID)
+
+	ctx := context.WithValue(r.Context(), traceContextKey{}, traceContext{traceID: traceID, spanID: spanID})
+	return r.WithContext(ctx), ctx
+}
+
+// Span is a lightweight, OpenTelemetry-style span: a named, timed unit of
+// work correlated to the request's trace via its traceparent.
+type Span struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	start      time.Time
+	attributes map[string]string
+}
+
+// StartSpan begins a new child span named name under the trace carried in
+// ctx (or a fresh trace if none is present), returning a context carrying
+// the new span so further children can nest under it.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent, _ := ctx.Value(traceContextKey{}).(traceContext)
+	traceID := parent.traceID
+	if traceID == "" {
+		traceID = newTraceID()
+	}
+
+	span := &Span{
+		Name:       name,
+		TraceID:    traceID,
+		SpanID:     newSpanID(),
+		ParentID:   parent.spanID,
+		start:      time.Now(),
+		attributes: make(map[string]string),
+	}
+
+	child := context.WithValue(ctx, traceContextKey{}, traceContext{traceID: traceID, spanID: span.SpanID})
+	return child, span
+}
+
+// SetAttribute attaches a key/value pair to the span, included when it ends.
+func (s *Span) SetAttribute(key, value string) {
+	s.attributes[key] = value
+}
+
+// End finalizes the span and emits it as a structured log record.
+func (s *Span) End() {
+	args := []any{
+		"trace_id", s.TraceID,
+		"span_id", s.SpanID,
+		"parent_id", s.ParentID,
+		"duration_ms", time.Since(s.start).Milliseconds(),
+	}
+	for k, v := range s.attributes {
+		args = append(args, k, v)
+	}
+	logger.Info("span "+s.Name, args...)
+}
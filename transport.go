@@ -0,0 +1,223 @@
+// transport.go
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// Transport proxies a request to a backend endpoint using a specific wire
+// protocol (plain HTTP, gRPC, or a WebSocket tunnel).
+type Transport interface {
+	// Name identifies the protocol this transport handles, matching the
+	// Service.Protocol value that selects it (e.g. "http", "grpc", "ws").
+	Name() string
+	// Forward proxies r to target, the fully-resolved backend URL, writing
+	// the response (or tunneling the connection) to w.
+	Forward(sm *ServiceMeshHandler, w http.ResponseWriter, r *http.Request, endpoint, target string)
+}
+
+// TransportRegistry maps protocol names to the Transport that handles them.
+type TransportRegistry struct {
+	mu         sync.RWMutex
+	transports map[string]Transport
+}
+
+// NewTransportRegistry creates a TransportRegistry pre-populated with the
+// built-in http, grpc, and ws transports.
+func NewTransportRegistry() *TransportRegistry {
+	tr := &TransportRegistry{transports: make(map[string]Transport)}
+	tr.Register(&HTTPTransport{})
+	tr.Register(NewGRPCTransport())
+	tr.Register(&WebSocketTransport{})
+	return tr
+}
+
+// Register adds or replaces the Transport for its Name().
+func (tr *TransportRegistry) Register(t Transport) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.transports[t.Name()] = t
+}
+
+// Get returns the Transport registered for protocol, if any.
+func (tr *TransportRegistry) Get(protocol string) (Transport, bool) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	t, ok := tr.transports[protocol]
+	return t, ok
+}
+
+// HTTPTransport proxies plain HTTP/1.1 and HTTP/2 requests.
+type HTTPTransport struct{}
+
+// Name implements Transport.
+func (t *HTTPTransport) Name() string { return "http" }
+
+// Forward implements Transport.
+func (t *HTTPTransport) Forward(sm *ServiceMeshHandler, w http.ResponseWriter, r *http.Request, endpoint, target string) {
+	sm.forwardRequest(w, r, endpoint, target, sm.Client)
+}
+
+// GRPCTransport proxies gRPC calls, which are HTTP/2 requests that carry
+// their final status in trailers sent after the response body. gRPC
+// mandates HTTP/2, including over plaintext ("h2c", negotiated by prior
+// knowledge rather than ALPN), so it dials with its own http2.Transport
+// instead of sm.Client's transport, which only negotiates HTTP/2 over TLS.
+type GRPCTransport struct {
+	client *http.Client
+}
+
+// NewGRPCTransport creates a GRPCTransport that proxies both plaintext
+// ("http://", h2c) and TLS ("https://") gRPC backends over real HTTP/2.
+func NewGRPCTransport() *GRPCTransport {
+	return &GRPCTransport{
+		client: &http.Client{
+			Transport: &http2.Transport{
+				// AllowHTTP plus a DialTLSContext that ignores the TLS
+				// config lets this Transport dial "http://" targets with
+				// HTTP/2 by prior knowledge (h2c); "https://" targets are
+				// unaffected since http2.Transport only consults DialTLS
+				// for the scheme it's configured to override.
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					if cfg == nil {
+						return (&net.Dialer{}).DialContext(ctx, network, addr)
+					}
+					return (&tls.Dialer{Config: cfg}).DialContext(ctx, network, addr)
+				},
+			},
+		},
+	}
+}
+
+// Name implements Transport.
+func (t *GRPCTransport) Name() string { return "grpc" }
+
+// Forward implements Transport.
+func (t *GRPCTransport) Forward(sm *ServiceMeshHandler, w http.ResponseWriter, r *http.Request, endpoint, target string) {
+	r.Header.Set("TE", "trailers")
+	sm.forwardRequest(w, r, endpoint, target, t.client)
+}
+
+// WebSocketTransport tunnels an upgraded WebSocket connection bidirectionally
+// to the backend, bypassing the request/response balancer stats and circuit
+// breaker machinery that only make sense for discrete request/response calls.
+type WebSocketTransport struct{}
+
+// Name implements Transport.
+func (t *WebSocketTransport) Name() string { return "ws" }
+
+// Forward implements Transport.
+func (t *WebSocketTransport) Forward(sm *ServiceMeshHandler, w http.ResponseWriter, r *http.Request, endpoint, target string) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket tunneling not supported by this connection", http.StatusInternalServerError)
+		return
+	}
+
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		http.Error(w, "Invalid WebSocket target", http.StatusBadGateway)
+		return
+	}
+
+	backendAddr, err := wsDialAddr(target)
+	if err != nil {
+		http.Error(w, "Invalid WebSocket target", http.StatusBadGateway)
+		return
+	}
+
+	backendConn, err := dialWSBackend(r.Context(), sm, targetURL.Scheme, backendAddr, targetURL.Hostname())
+	if err != nil {
+		logger.Error("failed to dial WebSocket backend", "addr", backendAddr, "error", err)
+		http.Error(w, "Failed to reach WebSocket backend", http.StatusServiceUnavailable)
+		return
+	}
+	defer backendConn.Close()
+
+	// Rewrite the request line to target's path before replaying it: r still
+	// carries the inbound "/serviceName/..." path, but the backend expects
+	// its own path with that mesh prefix stripped, same as the HTTP and gRPC
+	// transports already forward.
+	r.URL.Path = targetURL.Path
+	r.URL.RawPath = targetURL.RawPath
+	r.Host = targetURL.Host
+
+	// Replay the original upgrade request to the backend so it performs its
+	// own handshake with the client's headers (Sec-WebSocket-Key, etc).
+	if err := r.Write(backendConn); err != nil {
+		logger.Error("failed to forward WebSocket handshake", "addr", backendAddr, "error", err)
+		http.Error(w, "Failed to reach WebSocket backend", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientConn, clientBuf, err := hijacker.Hijack()
+	if err != nil {
+		logger.Error("failed to hijack client connection for WebSocket tunnel", "error", err)
+		return
+	}
+	defer clientConn.Close()
+
+	// Flush any bytes the client already sent past the hijack point.
+	if clientBuf.Reader.Buffered() > 0 {
+		buffered := make([]byte, clientBuf.Reader.Buffered())
+		clientBuf.Read(buffered)
+		backendConn.Write(buffered)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(backendConn, clientConn)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(clientConn, backendConn)
+	}()
+	wg.Wait()
+}
+
+// wsDialAddr extracts a host:port dial address from a ws(s):// or http(s):// target URL.
+func wsDialAddr(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		return u.Hostname() + ":443", nil
+	default:
+		return u.Hostname() + ":80", nil
+	}
+}
+
+// dialWSBackend dials addr for a WebSocket backend, using TLS when scheme is
+// "https" or "wss" so a secure backend's handshake isn't written to it in
+// plaintext. The TLS config is sourced from sm.TLSManager when set, so a
+// "wss://" backend is verified (and, for mTLS upstreams, authenticated) the
+// same way the mesh's other outbound connections are.
+func dialWSBackend(ctx context.Context, sm *ServiceMeshHandler, scheme, addr, host string) (net.Conn, error) {
+	if scheme != "https" && scheme != "wss" {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+
+	var tlsConfig *tls.Config
+	if sm.TLSManager != nil {
+		tlsConfig = sm.TLSManager.UpstreamTLSConfig(host)
+	} else {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+	return (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+}